@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+const (
+	// OptgenFieldTag is the struct tag with per-field generation options, e.g.
+	// `optgen:"alias=Port"` to keep the options of a renamed field working
+	OptgenFieldTag = "optgen"
+
+	// optgenAliasOption names a former name of the field, and
+	// optgenDeprecatedOption the message of the Deprecated: paragraph
+	optgenAliasOption      = "alias"
+	optgenDeprecatedOption = "deprecated"
+)
+
+// fieldOptions are the options of a field's optgen tag
+type fieldOptions struct {
+	// aliases are former names of the field, whose options are generated as
+	// deprecated forwards to the options of the field
+	aliases []string
+
+	// deprecated is the message of the Deprecated: paragraph of the aliases'
+	// options, or of the field's own options if it has no aliases
+	deprecated string
+}
+
+// parseOptgenTag returns the options of a field's optgen tag, e.g.
+// `optgen:"alias=Port,alias=ListenPort,deprecated=use WithAddr instead."`.
+// The deprecated message may contain commas, so it must come last.
+func parseOptgenTag(field *ast.Field) (fieldOptions, error) {
+	opts := fieldOptions{}
+	tag, err := debugmap.LookupTag(field, OptgenFieldTag)
+	if errors.Is(err, debugmap.ErrMissingTag) {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, err
+	}
+
+	for tag != "" {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			return opts, fmt.Errorf("expected key=value in optgen tag, got %q", tag)
+		}
+		switch strings.TrimSpace(key) {
+		case optgenDeprecatedOption:
+			opts.deprecated = strings.TrimSpace(value)
+			if opts.deprecated == "" {
+				return opts, errors.New("deprecated in optgen tag needs a message")
+			}
+			tag = ""
+		case optgenAliasOption:
+			value, tag, _ = strings.Cut(value, ",")
+			value = strings.TrimSpace(value)
+			if !token.IsIdentifier(value) || !token.IsExported(value) {
+				return opts, fmt.Errorf("alias %q in optgen tag is not an exported field name", value)
+			}
+			opts.aliases = append(opts.aliases, value)
+		default:
+			return opts, fmt.Errorf("unknown option %q in optgen tag", key)
+		}
+	}
+	return opts, nil
+}
+
+// optionAliases returns the functions generated for the aliases of a field,
+// mapped to the functions they forward to.
+func optionAliases(fieldName string, field *ast.Field, opts fieldOptions, c Config) map[string]string {
+	verbs := []string{"With"}
+	if isSliceOrArrayAST(field.Type) || isMapAST(field.Type) {
+		verbs = append(verbs, "Set")
+	}
+	aliases := make(map[string]string)
+	for _, alias := range opts.aliases {
+		for _, verb := range verbs {
+			aliases[c.optionFuncName(verb, alias)] = c.optionFuncName(verb, fieldName)
+		}
+	}
+	return aliases
+}
+
+// checkOptionAliases reports aliases that would generate the same functions
+// as a field or another alias.
+func checkOptionAliases(st *ast.StructType, c Config) {
+	fieldNames := make(map[string]struct{})
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			fieldNames[name.Name] = struct{}{}
+		}
+	}
+
+	aliasOf := make(map[string]string)
+	for _, field := range st.Fields.List {
+		opts, err := parseOptgenTag(field)
+		if err != nil || len(opts.aliases) == 0 {
+			continue
+		}
+		if len(field.Names) != 1 {
+			c.Diags.Addf(field.Tag.Pos(), "alias in optgen tag on fields %s in type %s: declare the fields separately to alias one of them", joinNames(field.Names), c.TargetTypeName)
+			continue
+		}
+		fieldName := field.Names[0].Name
+		for _, alias := range opts.aliases {
+			if _, ok := fieldNames[alias]; ok {
+				c.Diags.Addf(field.Tag.Pos(), "alias %s of field %s in type %s is the name of a field", alias, fieldName, c.TargetTypeName)
+				continue
+			}
+			if other, ok := aliasOf[alias]; ok {
+				c.Diags.Addf(field.Tag.Pos(), "alias %s of field %s in type %s is also an alias of field %s", alias, fieldName, c.TargetTypeName, other)
+				continue
+			}
+			aliasOf[alias] = fieldName
+		}
+	}
+}
+
+// joinNames returns the names of identifiers separated by commas.
+func joinNames(idents []*ast.Ident) string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeAliasOptsAST generates the options of a field's former names, which
+// forward to the field's options and are marked deprecated so that callers
+// are warned to move to the new names, e.g. with "optgen migrate".
+func writeAliasOptsAST(buf *jen.File, fieldName string, field *ast.Field, opts fieldOptions, fieldType jen.Code, c Config, resolver *ImportResolver) {
+	for _, alias := range opts.aliases {
+		writeAliasOpt := func(verb string, params []jen.Code, args []jen.Code) {
+			aliasFuncName := c.optionFuncName(verb, alias)
+			funcName := c.optionFuncName(verb, fieldName)
+			deprecated := opts.deprecated
+			if deprecated == "" {
+				deprecated = fmt.Sprintf("use %s instead.", funcName)
+			}
+			buf.Comment(fmt.Sprintf("%s is the name %s had before %s was renamed to %s", aliasFuncName, funcName, alias, fieldName))
+			buf.Comment("//")
+			buf.Comment("// Deprecated: " + deprecated)
+			buf.Func().Id(aliasFuncName).Params(params...).Id(c.OptTypeName).Block(
+				jen.Return(jen.Id(funcName).Call(args...)),
+			)
+		}
+
+		param := jen.Id(unexport(alias))
+		switch t := field.Type.(type) {
+		case *ast.ArrayType:
+			writeAliasOpt("With", []jen.Code{jen.Id(unexport(alias)).Add(astTypeToJenCode(t.Elt, resolver))}, []jen.Code{param})
+			writeAliasOpt("Set", []jen.Code{jen.Id(unexport(alias)).Add(fieldType)}, []jen.Code{param})
+		case *ast.MapType:
+			writeAliasOpt("With", []jen.Code{
+				jen.Id("key").Add(astTypeToJenCode(t.Key, resolver)),
+				jen.Id("value").Add(astTypeToJenCode(t.Value, resolver)),
+			}, []jen.Code{jen.Id("key"), jen.Id("value")})
+			writeAliasOpt("Set", []jen.Code{jen.Id(unexport(alias)).Add(fieldType)}, []jen.Code{param})
+		default:
+			writeAliasOpt("With", []jen.Code{jen.Id(unexport(alias)).Add(fieldType)}, []jen.Code{param})
+		}
+	}
+}
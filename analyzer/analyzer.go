@@ -0,0 +1,437 @@
+// Package analyzer provides a go/analysis Analyzer that reports mistakes in
+// the debugmap tags of optgen structs, and generated files that have fallen
+// behind their structs, without running the generator.
+//
+// It can be run with "go vet -vettool=$(which optgen-vet)" or added to any
+// driver that accepts analyzers, such as golangci-lint.
+package analyzer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/ecordell/optgen/internal/config"
+	"github.com/ecordell/optgen/internal/debugmap"
+	"github.com/ecordell/optgen/internal/directive"
+	"github.com/ecordell/optgen/internal/fingerprint"
+)
+
+const doc = `check debugmap struct tags of optgen structs
+
+The optgen analyzer reports the problems that optgen's DebugMap generation
+rejects: exported fields without a debugmap tag, unknown tag values and
+options, visible fields whose names or types look sensitive, and url-redacted
+fields that aren't strings. Missing tags and sensitive fields come with
+suggested fixes.
+
+Structs are checked if they have an //optgen:generate directive, a field with
+a debugmap tag, or generated optgen methods. Their settings come from the
+nearest optgen.yaml, optgen.yml or optgen.json, as for optgen, and explicitly
+set flags take precedence over it. The analyzer also reports
+generated files that need to be regenerated: files whose structs changed
+since they were generated, including exported fields that the generated
+ToOption doesn't copy, and files that were edited by hand.`
+
+// Analyzer checks the debugmap tags of optgen structs.
+var Analyzer = &analysis.Analyzer{
+	Name: "optgen",
+	Doc:  doc,
+	URL:  "https://pkg.go.dev/github.com/ecordell/optgen/analyzer",
+	Run:  run,
+}
+
+// Flags mirror the optgen flags that affect which tags are required. Their
+// defaults apply to the settings that the config file doesn't set.
+var (
+	sensitiveNames  string
+	allowedNames    string
+	sensitiveTypes  string
+	debugMapDefault string
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&sensitiveNames, "sensitive-field-name-matches", debugmap.DefaultSensitiveNames, "Comma-separated field name patterns that should be considered sensitive: substrings, globs (e.g. *Key) or regexes (e.g. re:Key$)")
+	Analyzer.Flags.StringVar(&allowedNames, "sensitive-field-name-allow", "", "Comma-separated field name patterns exempt from -sensitive-field-name-matches")
+	Analyzer.Flags.StringVar(&sensitiveTypes, "sensitive-types", "", "Comma-separated qualified type names (e.g. crypto/tls.Certificate) whose fields should be considered sensitive")
+	Analyzer.Flags.StringVar(&debugMapDefault, "debugmap-default", debugmap.DefaultError, "Policy for fields without a debugmap tag: hidden, visible, sensitive, or error")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	if len(pass.Files) == 0 {
+		return nil, nil
+	}
+	layers, err := loadConfigLayers(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := findGeneratedMethods(pass.Files)
+	structs := make(map[string]fingerprint.Struct)
+	for _, file := range pass.Files {
+		if isGeneratedFile(file) {
+			continue
+		}
+		for _, ts := range structDefs(file) {
+			structs[ts.Name.Name] = fingerprint.Struct{File: file, Spec: ts}
+			methods := generated[ts.Name.Name]
+			if methods.toOption != nil {
+				checkToOption(pass, ts, methods.toOption)
+			}
+			if methods.debugMap || directive.Has(file, ts, directive.Generate) || hasDebugMapTag(ts) {
+				rules, policy, err := layers.structSettings(ts.Name.Name, pass.Pkg)
+				if err != nil {
+					return nil, err
+				}
+				checkDebugMapTags(pass, file, ts, rules, policy)
+			}
+		}
+	}
+	for _, file := range pass.Files {
+		if isGeneratedFile(file) {
+			if err := checkHashes(pass, file, structs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// configLayers are the sources of settings for the structs of a package, from
+// lowest to highest precedence: the flag defaults, the config file, and
+// explicitly set flags.
+type configLayers struct {
+	// file is the config file, or nil if there is none
+	file   *config.File
+	pkgDir string
+	flags  *flag.FlagSet
+}
+
+// loadConfigLayers loads the config file for the package of pass.
+func loadConfigLayers(pass *analysis.Pass) (configLayers, error) {
+	pkgDir := filepath.Dir(pass.Fset.File(pass.Files[0].Package).Name())
+	file, err := config.Load(pkgDir)
+	if err != nil {
+		return configLayers{}, err
+	}
+	return configLayers{file: file, pkgDir: pkgDir, flags: &pass.Analyzer.Flags}, nil
+}
+
+// structSettings returns the sensitivity rules and the debugmap default of the
+// named struct of pkg.
+func (l configLayers) structSettings(name string, pkg *types.Package) (*debugmap.Rules, string, error) {
+	merged := flagConfig(l.flags.VisitAll)
+	if l.file != nil {
+		merged = merged.Merge(l.file.ForStruct(l.pkgDir, name))
+	}
+	merged = merged.Merge(flagConfig(l.flags.Visit))
+
+	if !debugmap.IsValidDefault(*merged.DebugMapDefault) {
+		return nil, "", fmt.Errorf("invalid debugmap-default %q for %s: must be one of %s", *merged.DebugMapDefault, name, strings.Join(debugmap.Defaults, ", "))
+	}
+	rules, err := debugmap.NewRules(merged.SensitiveFieldNameMatches, merged.SensitiveFieldNameAllow, merged.SensitiveTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	rules.Pkg = pkg
+	return rules, *merged.DebugMapDefault, nil
+}
+
+// flagConfig returns the settings of the flags that visit visits: all of them
+// with VisitAll, or the explicitly set ones with Visit.
+func flagConfig(visit func(func(*flag.Flag))) config.Generate {
+	var c config.Generate
+	visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "sensitive-field-name-matches":
+			c.SensitiveFieldNameMatches = splitList(sensitiveNames)
+		case "sensitive-field-name-allow":
+			c.SensitiveFieldNameAllow = splitList(allowedNames)
+		case "sensitive-types":
+			c.SensitiveTypes = splitList(sensitiveTypes)
+		case "debugmap-default":
+			policy := debugMapDefault
+			c.DebugMapDefault = &policy
+		}
+	})
+	return c
+}
+
+// checkHashes reports a generated file whose structs changed since it was
+// generated, or whose code no longer matches the hash in its header. Files
+// from versions of optgen that didn't record hashes are skipped.
+func checkHashes(pass *analysis.Pass, file *ast.File, structs map[string]fingerprint.Struct) error {
+	header := file.Comments[0].List
+	if len(header) < 2 {
+		return nil
+	}
+	recorded, ok := fingerprint.ParseLine(header[1].Text)
+	if !ok {
+		return nil
+	}
+
+	filename := pass.Fset.File(file.Pos()).Name()
+	readFile := pass.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	content, err := readFile(filename)
+	if err != nil {
+		return err
+	}
+	if fingerprint.Tampered(content, recorded) {
+		pass.Reportf(header[1].Pos(), "%s was edited by hand: its code no longer matches the hash in its header, rerun optgen to regenerate it", filepath.Base(filename))
+	}
+
+	sources := make([]fingerprint.Struct, 0)
+	for _, name := range receiverNames(file) {
+		s, ok := structs[name]
+		if !ok {
+			// The package doesn't compile, which the type checker reports
+			return nil
+		}
+		sources = append(sources, s)
+	}
+	if fingerprint.Source(pass.Fset, sources) != recorded.Source {
+		pass.Reportf(header[1].Pos(), "%s is stale because its structs changed, rerun optgen to regenerate it", filepath.Base(filename))
+	}
+	return nil
+}
+
+// checkDebugMapTags reports the problems with the debugmap tags of the
+// exported fields of ts, with untagged fields defaulting to policy.
+func checkDebugMapTags(pass *analysis.Pass, file *ast.File, ts *ast.TypeSpec, rules *debugmap.Rules, policy string) {
+	structName := ts.Name.Name
+
+	// Struct-level directives override the configured default
+	for _, d := range directive.ForType(file, ts) {
+		if d.Name != directive.DebugMapDefault {
+			continue
+		}
+		if !debugmap.IsValidDefault(d.Value) {
+			pass.Reportf(d.Comment.Pos(), "invalid %s%s value %q on type %s: must be one of %s", directive.Prefix, directive.DebugMapDefault, d.Value, structName, strings.Join(debugmap.Defaults, ", "))
+			continue
+		}
+		policy = d.Value
+	}
+
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			tag := debugmap.CheckField(field, name.Name, structName, structName, rules, policy)
+			for _, problem := range tag.Problems {
+				pass.Report(analysis.Diagnostic{
+					Pos:            problem.Pos,
+					Message:        problem.Message,
+					SuggestedFixes: tagFixes(field, problem.Fix),
+				})
+			}
+			if tag.Value == debugmap.URLRedacted && !isURLRedactable(pass.TypesInfo.TypeOf(field.Type)) {
+				pass.Reportf(field.Type.Pos(), "url-redacted field %s in type %s must be a string, *string or sql.NullString", name.Name, structName)
+			}
+		}
+	}
+}
+
+// tagFixes returns the suggested fix that sets the debugmap tag of field to
+// value, keeping its other tags, or nil if there's nothing to suggest.
+func tagFixes(field *ast.Field, value string) []analysis.SuggestedFix {
+	if value == "" {
+		return nil
+	}
+	tag, err := debugmap.SetTag(field, debugmap.Tag, value)
+	if err != nil {
+		return nil
+	}
+
+	edit := analysis.TextEdit{Pos: field.Type.End(), End: field.Type.End(), NewText: []byte(" " + tag)}
+	if field.Tag != nil {
+		edit = analysis.TextEdit{Pos: field.Tag.Pos(), End: field.Tag.End(), NewText: []byte(tag)}
+	}
+	return []analysis.SuggestedFix{{
+		Message:   fmt.Sprintf("Set %s:%q", debugmap.Tag, value),
+		TextEdits: []analysis.TextEdit{edit},
+	}}
+}
+
+// isURLRedactable reports whether t is one of the types that url-redacted
+// fields support.
+func isURLRedactable(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if basic, ok := t.(*types.Basic); ok {
+		return basic.Kind() == types.String
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "database/sql" && obj.Name() == "NullString"
+}
+
+// checkToOption reports the exported fields of ts that its generated ToOption
+// doesn't copy, which means the generated file predates them.
+func checkToOption(pass *analysis.Pass, ts *ast.TypeSpec, toOption *ast.FuncDecl) {
+	copied := copiedFields(toOption)
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if _, ok := copied[name.Name]; !ok {
+				pass.Reportf(name.Pos(), "field %s in type %s is missing from the generated ToOption: the generated file is stale, rerun optgen", name.Name, ts.Name.Name)
+			}
+		}
+	}
+}
+
+// copiedFields returns the names of the fields assigned in a generated
+// ToOption, which are all of the form "to.Field = c.Field".
+func copiedFields(toOption *ast.FuncDecl) map[string]struct{} {
+	copied := make(map[string]struct{})
+	ast.Inspect(toOption, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		for _, lhs := range assign.Lhs {
+			if sel, ok := lhs.(*ast.SelectorExpr); ok {
+				copied[sel.Sel.Name] = struct{}{}
+			}
+		}
+		return false
+	})
+	return copied
+}
+
+// generatedMethods are the optgen-generated methods of a struct
+type generatedMethods struct {
+	toOption *ast.FuncDecl
+	debugMap bool
+}
+
+// findGeneratedMethods returns the optgen-generated methods in files, by
+// receiver type name.
+func findGeneratedMethods(files []*ast.File) map[string]generatedMethods {
+	methods := make(map[string]generatedMethods)
+	for _, file := range files {
+		if !isGeneratedFile(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+				continue
+			}
+			recv := receiverName(fn.Recv.List[0].Type)
+			if recv == "" {
+				continue
+			}
+			m := methods[recv]
+			switch fn.Name.Name {
+			case "ToOption":
+				m.toOption = fn
+			case "DebugMap":
+				m.debugMap = true
+			}
+			methods[recv] = m
+		}
+	}
+	return methods
+}
+
+// receiverNames returns the names of the types with methods in file, in order.
+func receiverNames(file *ast.File) []string {
+	names := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+			continue
+		}
+		name := receiverName(fn.Recv.List[0].Type)
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// receiverName returns the type name of a method receiver such as "*Config"
+// or "*Config[T]".
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isGeneratedFile reports whether file was generated by optgen.
+func isGeneratedFile(file *ast.File) bool {
+	return len(file.Comments) > 0 &&
+		file.Comments[0].Pos() < file.Package &&
+		file.Comments[0].List[0].Text == fingerprint.Header
+}
+
+// hasDebugMapTag reports whether any field of ts has a debugmap tag.
+func hasDebugMapTag(ts *ast.TypeSpec) bool {
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		if _, err := debugmap.LookupTag(field, debugmap.Tag); !errors.Is(err, debugmap.ErrMissingTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// structDefs returns the struct types declared at the top level of file.
+func structDefs(file *ast.File) []*ast.TypeSpec {
+	found := make([]*ast.TypeSpec, 0)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				if _, isStruct := ts.Type.(*ast.StructType); isStruct {
+					found = append(found, ts)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,29 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/ecordell/optgen/analyzer"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzer.Analyzer, "tags")
+}
+
+func TestStaleToOption(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "stale")
+}
+
+func TestHashes(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "hashes")
+}
+
+func TestCommentChange(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "comments")
+}
+
+func TestConfigFile(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "configfile")
+}
@@ -0,0 +1,7 @@
+package comments
+
+type Config struct {
+	// Name is the name of the service
+	Name string `debugmap:"visible"`
+	Port int    `debugmap:"hidden"` // Port is the port to serve on
+}
@@ -0,0 +1,22 @@
+package configfile
+
+// Key is sensitive according to optgen.yaml.
+type Key struct {
+	Bytes []byte
+}
+
+//optgen:generate
+type Config struct {
+	Name     string `debugmap:"visible"`
+	APIToken string `debugmap:"visible"`
+	Signing  Key    `debugmap:"visible"` // want `field Signing in type Config has sensitive type configfile.Key and must be marked as 'sensitive'`
+	Port     int    // want `missing debugmap tag on field Port in type Config`
+}
+
+// Lenient defaults to hidden in optgen.yaml.
+//
+//optgen:generate
+type Lenient struct {
+	Name string `debugmap:"visible"`
+	Port int
+}
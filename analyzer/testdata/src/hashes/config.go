@@ -0,0 +1,6 @@
+package hashes
+
+type Config struct {
+	Name string `debugmap:"visible"`
+	Port int    `debugmap:"hidden"`
+}
@@ -0,0 +1,70 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=cfed801a92a0131d settings=353922762fa581ac body=97a008e49419224c // want `config_options.go is stale because its structs changed, rerun optgen to regenerate it`
+package hashes
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// DebugMap returns a map form of Config for debugging
+func (c *Config) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Config for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *Config) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = c.Name
+	}
+	debugMap["Port"] = c.Port
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Config for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *Config) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
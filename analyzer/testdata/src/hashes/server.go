@@ -0,0 +1,5 @@
+package hashes
+
+type Server struct {
+	Host string `debugmap:"visible"`
+}
@@ -0,0 +1,7 @@
+package stale
+
+type Config struct {
+	Name    string `debugmap:"visible"`
+	Port    int    `debugmap:"visible"`
+	Timeout int    `debugmap:"visible"` // want `field Timeout in type Config is missing from the generated ToOption: the generated file is stale, rerun optgen`
+}
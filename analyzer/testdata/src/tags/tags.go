@@ -0,0 +1,36 @@
+package tags
+
+import "database/sql"
+
+//optgen:generate
+type Config struct {
+	Name     string         `debugmap:"visible"`
+	Port     int            // want `missing debugmap tag on field Port in type Config`
+	Password string         `json:"password"` // want `missing debugmap tag on field Password in type Config`
+	Tags     []string       // want `missing debugmap tag on field Tags in type Config`
+	APIToken string         `debugmap:"visible"`       // want `field APIToken in type Config matches sensitive name pattern "token" and must be marked as 'sensitive'`
+	Level    string         `debugmap:"loud"`          // want `unknown value 'loud' for debugmap tag on field Level in type Config`
+	Mode     string         `debugmap:"visible,shout"` // want `unknown option 'shout' for debugmap tag on field Mode in type Config`
+	Secret   string         `debugmap:"visible,allow-sensitive-name"`
+	DSN      sql.NullString `debugmap:"url-redacted"`
+	Port2    int            `debugmap:"url-redacted"` // want `url-redacted field Port2 in type Config must be a string, \*string or sql.NullString`
+	internal string
+}
+
+// Hidden fields default to hidden.
+//
+//optgen:debugmap-default=hidden
+type Hidden struct {
+	Name string `debugmap:"visible"`
+	Port int
+}
+
+//optgen:debugmap-default=loud // want `invalid //optgen:debugmap-default value "loud" on type Loud: must be one of hidden, visible, sensitive, error`
+type Loud struct {
+	Name string `debugmap:"visible"`
+}
+
+// Unrelated structs aren't optgen structs.
+type Unrelated struct {
+	Port int
+}
@@ -0,0 +1,16 @@
+// Command optgen-vet runs the optgen analyzer, which checks the debugmap tags
+// of optgen structs, as a standalone tool or with go vet:
+//
+//	go install github.com/ecordell/optgen/cmd/optgen-vet@latest
+//	go vet -vettool=$(which optgen-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/ecordell/optgen/analyzer"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
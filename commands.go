@@ -0,0 +1,548 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+// command is an optgen subcommand
+type command struct {
+	// name is the subcommand, e.g. "generate"
+	name string
+
+	// usage are the command's synopses, without the leading "optgen"
+	usage []string
+
+	// summary is the one-line description shown in the command list
+	summary string
+
+	// help describes the command in detail, with examples
+	help string
+
+	// run executes the command with the arguments after its name
+	run func(cmd *command, args []string)
+}
+
+// commands are the optgen subcommands, in the order they are listed in the
+// usage text. "help" is handled by runCommand.
+var commands = []*command{
+	{
+		name: "generate",
+		usage: []string{
+			"generate [flags] <package-path> <struct-name>...",
+			"generate [flags] -all <package-path> [<package-pattern>...]",
+			"generate [flags] -package <name> - <struct-name>...",
+		},
+		summary: "generate options and DebugMap methods for structs (the default)",
+		help: `Generate writes functional options and DebugMap methods for the named structs,
+or with -all for every struct annotated with an //optgen:generate directive.
+
+Package arguments may be directories or go-style patterns such as ./..., which are
+generated in parallel. A package argument of "-" reads a single Go file from stdin
+and writes its options to stdout.
+
+"generate" is the default command, so "optgen [flags] <package-path> <struct-name>..."
+is the same as "optgen generate [flags] <package-path> <struct-name>...".
+
+Examples:
+
+	optgen generate -output=config_options.go . Config
+	optgen generate -prefix . Config Server
+	optgen generate -all ./...
+	optgen generate -package=config - Config < config.go`,
+		run: func(cmd *command, args []string) { runGenerate(cmd, args, false) },
+	},
+	{
+		name: "check",
+		usage: []string{
+			"check [flags] <package-path> <struct-name>...",
+			"check [flags] -all <package-path> [<package-pattern>...]",
+		},
+		summary: "verify that generated options are up to date",
+		help: `Check generates options like "optgen generate", but compares them with the existing
+outputs instead of writing them. It prints a unified diff and exits non-zero if
+any output is out of date, or if -output-pattern would remove stale files.
+
+Examples:
+
+	optgen check -output=config_options.go . Config
+	optgen check -all ./...`,
+		run: func(cmd *command, args []string) { runGenerate(cmd, args, true) },
+	},
+	{
+		name:    "list",
+		usage:   []string{"list [flags] [<package-path>|<package-pattern>...]"},
+		summary: "list structs and the state of their debugmap tags",
+		help: `List prints every struct in the packages (default ".") with the number of its
+exported fields that have a debugmap tag, whether it is annotated with an
+//optgen:generate directive, and whether generating it would fail because of
+missing tags.
+
+Examples:
+
+	optgen list
+	optgen list ./...`,
+		run: runList,
+	},
+	{
+		name:    "init",
+		usage:   []string{"init [flags] <package-path> <struct-name>..."},
+		summary: "add debugmap tags and a go:generate line to structs",
+		help: `Init prepares structs for optgen: it adds a debugmap tag to every untagged exported
+field, choosing "sensitive" for fields with sensitive names or types,
+"visible-format" for slices and maps and "visible" otherwise, and adds a
+//go:generate line for the structs to each file that doesn't have one for optgen
+yet. Explicitly set flags are added to the go:generate line.
+
+Review the chosen tags before committing them.
+
+Examples:
+
+	optgen init . Config
+	optgen init -prefix ./internal/server Config Server`,
+		run: runInit,
+	},
+	{
+		name: "tags",
+		usage: []string{
+			"tags [flags] <package-path> <struct-name>...",
+			"tags [flags] -all <package-path>",
+		},
+		summary: "add debugmap tags to untagged fields",
+		help: `Tags adds a debugmap tag to every untagged exported field of the named structs, or
+with -all of every struct annotated with an //optgen:generate directive. It chooses
+"sensitive" for fields whose names match -sensitive-field-name-matches or whose
+types are sensitive, "visible-format" for slices and maps and "visible" otherwise.
+Existing tags, comments and formatting are kept.
+
+With -dry-run, the changes are printed as a unified diff instead of written.
+
+Examples:
+
+	optgen tags . Config
+	optgen tags -dry-run -all ./internal/server`,
+		run: runTags,
+	},
+	{
+		name: "schema",
+		usage: []string{
+			"schema [flags] <package-path> <struct-name>",
+			"schema [flags] -output-dir <dir> <package-path> <struct-name>...",
+		},
+		summary: "write JSON Schema documents for structs",
+		help: `Schema writes a JSON Schema document describing the config document form of each
+named struct, as read by the XOptionsFromMap functions of the map emitter. Property
+names come from the json tags of the fields (or the yaml tags with -key-tag=yaml),
+and their types from the field types. Doc comments become descriptions, default
+tags defaults, and validate tags constraints such as required, min, max and oneof.
+Fields marked debugmap:"sensitive" are writeOnly, and structs of the same package
+are described once in $defs and referenced.
+
+A single schema is printed to stdout; with -output-dir, each schema is written to
+<struct_name>.schema.json in the directory.
+
+Examples:
+
+	optgen schema . Config > config.schema.json
+	optgen schema -output-dir=schemas ./internal/server Config Server`,
+		run: runSchema,
+	},
+	{
+		name:    "docs",
+		usage:   []string{"docs [flags] [-format=markdown|html] <package-path> <struct-name>..."},
+		summary: "print a reference of the options generated for structs",
+		help: `Docs prints a reference of the options generated for the named structs: a section
+per struct with a table of its option functions, their parameters, whether they set,
+append to or replace their field, the field's default tag, how DebugMap shows it and
+its doc comment. Option names follow -prefix and -option-name-template like
+"optgen generate".
+
+The reference is Markdown by default; -format=html writes an HTML fragment with a
+<section> per struct instead.
+
+Examples:
+
+	optgen docs . Config > docs/config.md
+	optgen docs -format=html -prefix ./internal/server Config Server`,
+		run: runDocs,
+	},
+	{
+		name:    "migrate",
+		usage:   []string{"migrate [flags] [-dry-run] <package-path> [<package-pattern>...]"},
+		summary: "rewrite references to aliased options to their new names",
+		help: `Migrate rewrites the references to the deprecated options that an optgen:"alias=..."
+tag keeps for a renamed field, e.g. WithAddr to WithListenAddr. It reads the aliases of
+every struct in the package and rewrites the package itself and the packages matching
+the patterns (default "./..."), including tests; generated files are left alone. Pass
+the same -prefix and -option-name-template as "optgen generate" so the names match.
+References are resolved with type information to the generated alias functions, so
+generate the aliases first.
+
+Once no references are left, remove the alias and regenerate.
+
+With -dry-run, the changes are printed as a unified diff instead of written.
+
+Examples:
+
+	optgen migrate ./internal/config
+	optgen migrate -dry-run -prefix ./internal/config ./cmd/... ./internal/...`,
+		run: runMigrate,
+	},
+	{
+		name:    "config",
+		usage:   []string{"config print [flags] [<package-path>] [<struct-name>...]"},
+		summary: "print the effective configuration",
+		help: `Config print shows the settings of a package (default ".") and its structs after
+merging the built-in defaults, the optgen.yaml or optgen.json config file and
+explicitly set flags.
+
+Examples:
+
+	optgen config print
+	optgen config print -prefix ./internal/server Config`,
+		run: runConfig,
+	},
+	{
+		name:    "version",
+		usage:   []string{"version"},
+		summary: "print version information",
+		help: `Version prints the version of optgen and the Go version it was built with.
+
+Examples:
+
+	optgen version`,
+		run: runVersion,
+	},
+}
+
+// runCommand runs the subcommand named by the first argument. Arguments
+// that don't start with a command name are passed to "generate", which keeps
+// "optgen [flags] <package-path> <struct-name>..." working.
+func runCommand(args []string) {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	// A directory named like a command is a package to generate for, as it
+	// was before optgen had commands
+	if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+		cmd := lookupCommand("generate")
+		cmd.run(cmd, args)
+		return
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) < 2 {
+			printUsage(os.Stdout)
+			return
+		}
+		cmd := lookupCommand(args[1])
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "optgen help %s: unknown command\n", args[1])
+			printUsage(os.Stderr)
+			os.Exit(2)
+		}
+		// The commands define their own flags, so let them print their usage
+		cmd.run(cmd, []string{"-h"})
+		return
+	case "-version", "--version":
+		args[0] = "version"
+	}
+
+	if cmd := lookupCommand(args[0]); cmd != nil {
+		cmd.run(cmd, args[1:])
+		return
+	}
+	cmd := lookupCommand("generate")
+	cmd.run(cmd, args)
+}
+
+// lookupCommand returns the named command, or nil if there is none.
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// printUsage writes the top-level usage text to w.
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `optgen generates functional options and DebugMap methods for Go structs.
+
+Usage:
+
+	optgen <command> [flags] [arguments]
+
+Commands:
+
+`)
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t%-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+	fmt.Fprint(w, `
+Without a command, optgen runs "generate". A first argument that names an existing
+directory is always a package directory, even if it is also the name of a command:
+"optgen docs" in a directory with a docs subdirectory generates for ./docs.
+
+Run "optgen help <command>" for more information about a command.
+`)
+}
+
+// flagSet returns a new FlagSet for the command whose usage is the command's
+// help text.
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("optgen "+c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		c.printUsage(fs.Output(), fs)
+	}
+	return fs
+}
+
+// printUsage writes the command's synopses, help text and flags to w.
+func (c *command) printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w)
+	for _, usage := range c.usage {
+		fmt.Fprintf(w, "\toptgen %s\n", usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, c.help)
+
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		fmt.Fprintln(w)
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+// parse parses the command's flags, exiting on invalid flags, or after
+// printing the usage text for -h.
+func (c *command) parse(fs *flag.FlagSet, args []string) {
+	// The FlagSet already printed the error and usage
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+}
+
+// usageError reports invalid arguments along with the command's usage and
+// exits.
+func (c *command) usageError(fs *flag.FlagSet, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "optgen %s: %s\n\n", c.name, fmt.Sprintf(format, args...))
+	c.printUsage(os.Stderr, fs)
+	os.Exit(2)
+}
+
+// configFlags are the flags that override settings of the config file
+type configFlags struct {
+	output             *string
+	outputPattern      *string
+	pkgName            *string
+	prefix             *bool
+	optionNameTemplate *string
+	emitters           *string
+	flagPackage        *string
+	sensitiveNames     *string
+	allowedNames       *string
+	sensitiveTypes     *string
+	debugMapDefault    *string
+	debugMapMaxDepth   *int
+}
+
+// addConfigFlags defines the config flags on fs.
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	return &configFlags{
+		output: fs.String(
+			"output",
+			"",
+			"Location where generated options will be written, or - for stdout",
+		),
+		outputPattern: fs.String(
+			"output-pattern",
+			"",
+			"text/template for a separate output file per struct, relative to the package (e.g. {{.Struct | snake}}_options.go)",
+		),
+		pkgName: fs.String(
+			"package",
+			"",
+			"Name of package to use in output file",
+		),
+		prefix: fs.Bool(
+			"prefix",
+			false,
+			"Prefix generated function names with struct name (e.g., WithServerPort instead of WithPort)",
+		),
+		optionNameTemplate: fs.String(
+			"option-name-template",
+			"",
+			"text/template for option function names using .Verb, .Struct and .Field (e.g. {{.Verb}}{{.Struct}}{{.Field}}); overrides -prefix",
+		),
+		emitters: fs.String(
+			"emitters",
+			strings.Join(defaultEmitters, ","),
+			"Comma-separated list of code to generate: options, debugmap, flags, env, map",
+		),
+		flagPackage: fs.String(
+			"flag-package",
+			FlagPackageFlag,
+			"Flag package used by the flags emitter: flag or pflag (github.com/spf13/pflag)",
+		),
+		sensitiveNames: fs.String(
+			"sensitive-field-name-matches",
+			debugmap.DefaultSensitiveNames,
+			"Comma-separated field name patterns that should be considered sensitive: substrings, globs (e.g. *Key) or regexes (e.g. re:Key$)",
+		),
+		allowedNames: fs.String(
+			"sensitive-field-name-allow",
+			"",
+			"Comma-separated field name patterns exempt from -sensitive-field-name-matches",
+		),
+		sensitiveTypes: fs.String(
+			"sensitive-types",
+			"",
+			"Comma-separated qualified type names (e.g. crypto/tls.Certificate) whose fields should be considered sensitive",
+		),
+		debugMapDefault: fs.String(
+			"debugmap-default",
+			debugmap.DefaultError,
+			"Policy for fields without a debugmap tag: hidden, visible, sensitive, or error",
+		),
+		debugMapMaxDepth: fs.Int(
+			"debugmap-max-depth",
+			DefaultDebugMapMaxDepth,
+			"Nesting depth past which generated DebugMap methods stop expanding nested structs",
+		),
+	}
+}
+
+// config validates the parsed flags and returns the explicitly set ones,
+// which take precedence over the config file.
+func (f *configFlags) config(fs *flag.FlagSet) (GenerateConfig, error) {
+	if *f.debugMapMaxDepth < 0 {
+		return GenerateConfig{}, errors.New("-debugmap-max-depth must not be negative")
+	}
+	if !debugmap.IsValidDefault(*f.debugMapDefault) {
+		return GenerateConfig{}, fmt.Errorf("invalid -debugmap-default %q: must be one of %s", *f.debugMapDefault, strings.Join(debugmap.Defaults, ", "))
+	}
+
+	config := GenerateConfig{}
+	fs.Visit(func(flag *flag.Flag) {
+		switch flag.Name {
+		case "output":
+			config.Output = f.output
+		case "output-pattern":
+			config.OutputPattern = f.outputPattern
+		case "package":
+			config.Package = f.pkgName
+		case "prefix":
+			config.Prefix = f.prefix
+		case "option-name-template":
+			config.OptionNameTemplate = f.optionNameTemplate
+		case "emitters":
+			config.Emitters = splitList(*f.emitters)
+		case "flag-package":
+			config.FlagPackage = f.flagPackage
+		case "sensitive-field-name-matches":
+			config.SensitiveFieldNameMatches = splitList(*f.sensitiveNames)
+		case "sensitive-field-name-allow":
+			config.SensitiveFieldNameAllow = splitList(*f.allowedNames)
+		case "sensitive-types":
+			config.SensitiveTypes = splitList(*f.sensitiveTypes)
+		case "debugmap-default":
+			config.DebugMapDefault = f.debugMapDefault
+		case "debugmap-max-depth":
+			config.DebugMapMaxDepth = f.debugMapMaxDepth
+		}
+	})
+
+	if config.Output != nil && config.OutputPattern != nil {
+		return GenerateConfig{}, errors.New("-output and -output-pattern can't be used together")
+	}
+	return config, nil
+}
+
+// runConfig runs "optgen config print", which shows the effective settings of
+// a package and its structs.
+func runConfig(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	if len(args) == 0 || args[0] != "print" {
+		cmd.parse(fs, args)
+		cmd.usageError(fs, "unknown or missing subcommand, only \"print\" is supported")
+	}
+	cmd.parse(fs, args[1:])
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkgDir := "."
+	if fs.NArg() > 0 {
+		pkgDir = fs.Arg(0)
+	}
+	layers, err := loadConfigLayers(pkgDir, flagConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := PrintEffectiveConfig(os.Stdout, layers, fs.Args()[min(1, fs.NArg()):]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runVersion prints the module version and build information of optgen.
+func runVersion(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	cmd.parse(fs, args)
+	if fs.NArg() > 0 {
+		cmd.usageError(fs, "unexpected arguments")
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		fmt.Println("optgen (unknown version)")
+		return
+	}
+	fmt.Printf("optgen %s\n", info.Main.Version)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "  module:\t%s\n", info.Main.Path)
+	fmt.Fprintf(tw, "  go:\t%s\n", info.GoVersion)
+	settings := make(map[string]string)
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision := settings["vcs.revision"]; revision != "" {
+		if settings["vcs.modified"] == "true" {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(tw, "  revision:\t%s\n", revision)
+	}
+	if buildTime := settings["vcs.time"]; buildTime != "" {
+		fmt.Fprintf(tw, "  committed:\t%s\n", buildTime)
+	}
+	_ = tw.Flush()
+}
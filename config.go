@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ecordell/optgen/internal/config"
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+const (
+	// EmitterOptions generates the option type, constructors and With* functions
+	EmitterOptions = "options"
+
+	// EmitterDebugMap generates the DebugMap and FlatDebugMap methods
+	EmitterDebugMap = "debugmap"
+
+	// EmitterFlags generates RegisterXFlags for fields with a flag tag. It
+	// needs the option type of EmitterOptions.
+	EmitterFlags = "flags"
+
+	// EmitterEnv generates XOptionsFromEnv, which reads fields from
+	// environment variables. It needs the option type of EmitterOptions.
+	EmitterEnv = "env"
+
+	// EmitterMap generates XOptionsFromMap and the XOptionsFromJSON and
+	// XOptionsFromYAML wrappers, which read fields from config documents. It
+	// needs the option type of EmitterOptions.
+	EmitterMap = "map"
+)
+
+// emitters are the valid values of the emitters setting, in generation order
+var emitters = []string{EmitterOptions, EmitterDebugMap, EmitterFlags, EmitterEnv, EmitterMap}
+
+// defaultEmitters are the emitters used unless the emitters setting is set;
+// the others are opt-in
+var defaultEmitters = []string{EmitterOptions, EmitterDebugMap}
+
+// ConfigFile and GenerateConfig are a config file and one level of its
+// settings, which the analyzer reads too.
+type (
+	ConfigFile     = config.File
+	GenerateConfig = config.Generate
+)
+
+// defaultGenerateConfig returns the built-in settings, which every other level
+// of configuration overrides.
+func defaultGenerateConfig() GenerateConfig {
+	empty, prefix, flagPackage, debugMapDefault, maxDepth := "", false, FlagPackageFlag, debugmap.DefaultError, DefaultDebugMapMaxDepth
+	return GenerateConfig{
+		Output:                    &empty,
+		OutputPattern:             &empty,
+		Package:                   &empty,
+		Prefix:                    &prefix,
+		OptionNameTemplate:        &empty,
+		Emitters:                  defaultEmitters,
+		FlagPackage:               &flagPackage,
+		SensitiveFieldNameMatches: splitList(debugmap.DefaultSensitiveNames),
+		SensitiveFieldNameAllow:   []string{},
+		SensitiveTypes:            []string{},
+		DebugMapDefault:           &debugMapDefault,
+		DebugMapMaxDepth:          &maxDepth,
+	}
+}
+
+// newSettings validates a fully merged config and converts it into generation
+// settings. pkgTypes is the type-checked package of the structs, if available.
+func newSettings(c GenerateConfig, pkgTypes *types.Package) (Settings, error) {
+	if *c.DebugMapMaxDepth < 0 {
+		return Settings{}, errors.New("debugmap-max-depth must not be negative")
+	}
+	if !debugmap.IsValidDefault(*c.DebugMapDefault) {
+		return Settings{}, fmt.Errorf("invalid debugmap-default %q: must be one of %s", *c.DebugMapDefault, strings.Join(debugmap.Defaults, ", "))
+	}
+	for _, emitter := range c.Emitters {
+		if !contains(emitters, emitter) {
+			return Settings{}, fmt.Errorf("invalid emitter %q: must be one of %s", emitter, strings.Join(emitters, ", "))
+		}
+	}
+	for _, emitter := range []string{EmitterFlags, EmitterEnv, EmitterMap} {
+		if contains(c.Emitters, emitter) && !contains(c.Emitters, EmitterOptions) {
+			return Settings{}, fmt.Errorf("emitter %q requires emitter %q", emitter, EmitterOptions)
+		}
+	}
+	if !contains(flagPackages, *c.FlagPackage) {
+		return Settings{}, fmt.Errorf("invalid flag-package %q: must be one of %s", *c.FlagPackage, strings.Join(flagPackages, ", "))
+	}
+
+	var nameTemplate *template.Template
+	if *c.OptionNameTemplate != "" {
+		tmpl, err := template.New("option-name-template").Option("missingkey=error").Parse(*c.OptionNameTemplate)
+		if err != nil {
+			return Settings{}, fmt.Errorf("invalid option-name-template: %w", err)
+		}
+		// Check that the template produces identifiers
+		var name strings.Builder
+		if err := tmpl.Execute(&name, optionNameData{Verb: "With", Struct: "Config", Field: "Name"}); err != nil {
+			return Settings{}, fmt.Errorf("invalid option-name-template: %w", err)
+		}
+		if !token.IsIdentifier(name.String()) {
+			return Settings{}, fmt.Errorf("invalid option-name-template: %q is not a valid function name", name.String())
+		}
+		nameTemplate = tmpl
+	}
+
+	var outputPattern *template.Template
+	if c.OutputPattern != nil && *c.OutputPattern != "" {
+		tmpl, err := parseOutputPattern(*c.OutputPattern)
+		if err != nil {
+			return Settings{}, err
+		}
+		outputPattern = tmpl
+	}
+
+	rules, err := debugmap.NewRules(c.SensitiveFieldNameMatches, c.SensitiveFieldNameAllow, c.SensitiveTypes)
+	if err != nil {
+		return Settings{}, err
+	}
+	rules.Pkg = pkgTypes
+
+	return Settings{
+		Rules:              rules,
+		UsePrefix:          *c.Prefix,
+		OptionNameTemplate: nameTemplate,
+		OutputPattern:      outputPattern,
+		Emitters:           c.Emitters,
+		FlagPackage:        *c.FlagPackage,
+		DebugMapMaxDepth:   *c.DebugMapMaxDepth,
+		DebugMapDefault:    *c.DebugMapDefault,
+	}, nil
+}
+
+// optionNameData is the data passed to option name templates
+type optionNameData struct {
+	Verb   string
+	Struct string
+	Field  string
+}
+
+// ConfigLayers are the sources of settings for a package, from lowest to
+// highest precedence: the built-in defaults, the config file's defaults, its
+// package and struct settings, and explicitly set flags.
+type ConfigLayers struct {
+	// File is the config file, or nil if there is none
+	File   *ConfigFile
+	PkgDir string
+	Flags  GenerateConfig
+}
+
+// loadConfigLayers loads the config file for the package in pkgDir and
+// layers the explicitly set flags on top of it.
+func loadConfigLayers(pkgDir string, flagConfig GenerateConfig) (ConfigLayers, error) {
+	file, err := config.Load(pkgDir)
+	if err != nil {
+		return ConfigLayers{}, err
+	}
+	return ConfigLayers{File: file, PkgDir: pkgDir, Flags: flagConfig}, nil
+}
+
+// Package returns the merged settings of the package.
+func (l ConfigLayers) Package() GenerateConfig {
+	return l.merge("")
+}
+
+// Struct returns the merged settings of the named struct.
+func (l ConfigLayers) Struct(name string) GenerateConfig {
+	return l.merge(name)
+}
+
+func (l ConfigLayers) merge(structName string) GenerateConfig {
+	fromFile := GenerateConfig{}
+	if l.File != nil {
+		fromFile = l.File.ForStruct(l.PkgDir, structName)
+	}
+	// Outputs in the config file are relative to the package
+	if fromFile.Output != nil && *fromFile.Output != "" && !filepath.IsAbs(*fromFile.Output) {
+		output := filepath.Join(l.PkgDir, *fromFile.Output)
+		fromFile.Output = &output
+	}
+
+	return defaultGenerateConfig().Merge(fromFile).Merge(l.Flags)
+}
+
+// structNames returns the names of the structs configured for the package.
+func (l ConfigLayers) structNames() []string {
+	if l.File == nil {
+		return nil
+	}
+	names := make([]string, 0)
+	for name := range l.File.ForPackage(l.PkgDir).Structs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// PrintEffectiveConfig writes the merged settings of the package and of the
+// given structs, plus any structs configured in the config file, as YAML.
+func PrintEffectiveConfig(w io.Writer, layers ConfigLayers, structNames []string) error {
+	if layers.File != nil {
+		if _, err := fmt.Fprintf(w, "# config file: %s\n", layers.File.Path); err != nil {
+			return err
+		}
+	} else if _, err := fmt.Fprintln(w, "# no config file found"); err != nil {
+		return err
+	}
+
+	names := append(append([]string{}, structNames...), layers.structNames()...)
+	sort.Strings(names)
+
+	effective := struct {
+		Package GenerateConfig            `yaml:"package"`
+		Structs map[string]GenerateConfig `yaml:"structs,omitempty"`
+	}{
+		Package: layers.Package(),
+		Structs: make(map[string]GenerateConfig, len(names)),
+	}
+	for _, name := range names {
+		effective.Structs[name] = layers.Struct(name)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(effective); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,324 @@
+// Package decode provides runtime helpers used by optgen-generated
+// XOptionsFromMap functions to convert decoded config documents into field
+// values strictly: a value of the wrong type, an integer that overflows its
+// field or a key that matches no field is an error rather than a zero value.
+package decode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a value that can't be converted to its field, or keys that match
+// no field
+type Error struct {
+	// Path is the key of the value, with the keys of the structs it is nested
+	// in and the indexes of slices separated by dots, e.g. "database.hosts.0".
+	// It is empty for unknown keys at the top level.
+	Path string
+
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Within prefixes the path of a conversion error from a nested struct with
+// the key of the struct. Other errors are returned unchanged.
+func Within(key string, err error) error {
+	var decodeErr *Error
+	if !errors.As(err, &decodeErr) {
+		return err
+	}
+	path := key
+	if decodeErr.Path != "" {
+		path += "." + decodeErr.Path
+	}
+	return &Error{Path: path, Message: decodeErr.Message}
+}
+
+// JSON decodes a JSON object. Numbers are kept as json.Number so that integers
+// convert without losing precision.
+func JSON(r io.Reader) (map[string]any, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	m := map[string]any{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+	return m, nil
+}
+
+// YAML decodes a YAML mapping. An empty document decodes to an empty map.
+func YAML(r io.Reader) (map[string]any, error) {
+	m := map[string]any{}
+	if err := yaml.NewDecoder(r).Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding YAML: %w", err)
+	}
+	return m, nil
+}
+
+// CheckKeys returns an error listing the keys of m that aren't known.
+func CheckKeys(m map[string]any, known ...string) error {
+	unknown := make([]string, 0)
+	for key := range m {
+		if !contains(known, key) {
+			unknown = append(unknown, strconv.Quote(key))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	if len(unknown) == 1 {
+		return &Error{Message: "unknown key " + unknown[0]}
+	}
+	return &Error{Message: "unknown keys " + strings.Join(unknown, ", ")}
+}
+
+// Lookup returns the value of the first of keys that is set in m, and that
+// key. Fields whose json and yaml tags differ can be set with either.
+func Lookup(m map[string]any, keys ...string) (any, string, bool) {
+	for _, key := range keys {
+		if value, ok := m[key]; ok {
+			return value, key, true
+		}
+	}
+	return nil, "", false
+}
+
+// String converts a string value.
+func String[T ~string](key string, value any) (T, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", typeError(key, "a string", value)
+	}
+	return T(s), nil
+}
+
+// Bool converts a boolean value.
+func Bool[T ~bool](key string, value any) (T, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, typeError(key, "a boolean", value)
+	}
+	return T(b), nil
+}
+
+// Int converts an integer value. Floats are accepted only if they are whole
+// numbers, and values that overflow T are an error.
+func Int[T ~int | ~int8 | ~int16 | ~int32 | ~int64](key string, value any) (T, error) {
+	n, ok := toInt64(value)
+	if !ok {
+		return 0, typeError(key, "an integer", value)
+	}
+	if int64(T(n)) != n {
+		return 0, &Error{Path: key, Message: fmt.Sprintf("%d overflows %T", n, T(0))}
+	}
+	return T(n), nil
+}
+
+// Uint converts a non-negative integer value. Floats are accepted only if
+// they are whole numbers, and values that overflow T are an error.
+func Uint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](key string, value any) (T, error) {
+	n, ok := toUint64(value)
+	if !ok {
+		return 0, typeError(key, "a non-negative integer", value)
+	}
+	if uint64(T(n)) != n {
+		return 0, &Error{Path: key, Message: fmt.Sprintf("%d overflows %T", n, T(0))}
+	}
+	return T(n), nil
+}
+
+// Float converts a number.
+func Float[T ~float32 | ~float64](key string, value any) (T, error) {
+	switch v := value.(type) {
+	case float64:
+		return T(v), nil
+	case float32:
+		return T(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, typeError(key, "a number", value)
+		}
+		return T(f), nil
+	}
+	if n, ok := toInt64(value); ok {
+		return T(n), nil
+	}
+	if n, ok := toUint64(value); ok {
+		return T(n), nil
+	}
+	return 0, typeError(key, "a number", value)
+}
+
+// Duration converts a duration string, e.g. "1m30s".
+func Duration[T ~int64](key string, value any) (T, error) {
+	switch v := value.(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, &Error{Path: key, Message: err.Error()}
+		}
+		return T(d), nil
+	case time.Duration:
+		return T(v), nil
+	}
+	return 0, typeError(key, "a duration string", value)
+}
+
+// Slice converts a list, converting each item with item.
+func Slice[T any](key string, value any, item func(string, any) (T, error)) ([]T, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, typeError(key, "a list", value)
+	}
+	converted := make([]T, 0, len(items))
+	for i, v := range items {
+		c, err := item(key+"."+strconv.Itoa(i), v)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, c)
+	}
+	return converted, nil
+}
+
+// Map converts a mapping with string keys, converting each value with item.
+func Map[K ~string, V any](key string, value any, item func(string, any) (V, error)) (map[K]V, error) {
+	m, err := Object(key, value)
+	if err != nil {
+		return nil, err
+	}
+	converted := make(map[K]V, len(m))
+	for k, v := range m {
+		c, err := item(key+"."+k, v)
+		if err != nil {
+			return nil, err
+		}
+		converted[K(k)] = c
+	}
+	return converted, nil
+}
+
+// Object converts a mapping with string keys, such as a nested struct.
+func Object(key string, value any) (map[string]any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, nil
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			s, ok := k.(string)
+			if !ok {
+				return nil, &Error{Path: key, Message: fmt.Sprintf("expected string keys, got %T", k)}
+			}
+			m[s] = item
+		}
+		return m, nil
+	}
+	return nil, typeError(key, "a mapping", value)
+}
+
+func typeError(key, want string, value any) error {
+	return &Error{Path: key, Message: fmt.Sprintf("expected %s, got %s", want, describe(value))}
+}
+
+// describe returns the kind of a decoded value in document terms, e.g. "a
+// number" rather than json.Number. Values are left out since they may be
+// secrets.
+func describe(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "a number"
+	case []any:
+		return "a list"
+	case map[string]any, map[any]any:
+		return "a mapping"
+	}
+	return fmt.Sprintf("a %T", value)
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float64:
+		return int64(v), v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return toInt64(f)
+	}
+	return 0, false
+}
+
+func toUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case uint:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case json.Number:
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return n, true
+		}
+	case float64:
+		return uint64(v), v == math.Trunc(v) && v >= 0 && v < math.MaxUint64
+	}
+	n, ok := toInt64(value)
+	return uint64(n), ok && n >= 0
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package decode_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ecordell/optgen/decode"
+)
+
+type level int8
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    level
+		wantErr string
+	}{
+		{"int", 3, 3, ""},
+		{"json number", json.Number("-7"), -7, ""},
+		{"whole json float", json.Number("5.0"), 5, ""},
+		{"whole float", 4.0, 4, ""},
+		{"fraction", 1.5, 0, "level: expected an integer, got a number"},
+		{"overflow", 300, 0, "level: 300 overflows decode_test.level"},
+		{"string", "3", 0, "level: expected an integer, got a string"},
+		{"null", nil, 0, "level: expected an integer, got null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decode.Int[level]("level", tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUint(t *testing.T) {
+	if got, err := decode.Uint[uint64]("n", json.Number("18446744073709551615")); err != nil || got != 1<<64-1 {
+		t.Errorf("got %v, %v; want max uint64", got, err)
+	}
+	if _, err := decode.Uint[uint]("n", -1); err == nil || err.Error() != "n: expected a non-negative integer, got a number" {
+		t.Errorf("unexpected error for a negative value: %v", err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	if got, err := decode.Duration[time.Duration]("timeout", "1m30s"); err != nil || got != 90*time.Second {
+		t.Errorf("got %v, %v; want 1m30s", got, err)
+	}
+	if _, err := decode.Duration[time.Duration]("timeout", "soon"); err == nil || !strings.HasPrefix(err.Error(), "timeout: time: invalid duration") {
+		t.Errorf("unexpected error for an invalid duration: %v", err)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	items, err := decode.Slice("hosts", []any{"a", "b"}, decode.String[string])
+	if err != nil || strings.Join(items, ",") != "a,b" {
+		t.Errorf("got %v, %v; want [a b]", items, err)
+	}
+	if _, err := decode.Slice("hosts", []any{"a", 1}, decode.String[string]); err == nil || err.Error() != "hosts.1: expected a string, got a number" {
+		t.Errorf("unexpected error for a bad item: %v", err)
+	}
+
+	m, err := decode.Map[string]("limits", map[any]any{"read": 10}, decode.Int[int])
+	if err != nil || m["read"] != 10 {
+		t.Errorf("got %v, %v; want map[read:10]", m, err)
+	}
+	if _, err := decode.Map[string]("limits", map[any]any{1: 10}, decode.Int[int]); err == nil || err.Error() != "limits: expected string keys, got int" {
+		t.Errorf("unexpected error for a non-string key: %v", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	m := map[string]any{"port": 1, "listen_port": 2, "nmae": "x"}
+	if err := decode.CheckKeys(m, "port", "listen_port", "name"); err == nil || err.Error() != `unknown key "nmae"` {
+		t.Errorf("unexpected error for an unknown key: %v", err)
+	}
+	if value, key, ok := decode.Lookup(m, "port", "listen_port"); !ok || key != "port" || value != 1 {
+		t.Errorf("expected the first key to win, got %v %q %v", value, key, ok)
+	}
+
+	err := decode.Within("server", decode.Within("tls", decode.CheckKeys(m, "port", "listen_port")))
+	var decodeErr *decode.Error
+	if !errors.As(err, &decodeErr) || decodeErr.Path != "server.tls" || err.Error() != `server.tls: unknown key "nmae"` {
+		t.Errorf("unexpected nested error: %v", err)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	m, err := decode.JSON(strings.NewReader(`{"port": 9007199254740993}`))
+	if err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if port, err := decode.Int[int64]("port", m["port"]); err != nil || port != 9007199254740993 {
+		t.Errorf("expected integers to keep their precision, got %v, %v", port, err)
+	}
+
+	m, err = decode.YAML(strings.NewReader(""))
+	if err != nil || len(m) != 0 {
+		t.Errorf("expected an empty document to decode to an empty map, got %v, %v", m, err)
+	}
+	if _, err := decode.YAML(strings.NewReader("- a\n- b\n")); err == nil {
+		t.Errorf("expected an error for a YAML list")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Diagnostic is a generation error at a position in the source.
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+// String formats the diagnostic as "file:line:col: message", which editors
+// can jump to.
+func (d Diagnostic) String() string {
+	if !d.Pos.IsValid() {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// Diagnostics accumulates generation errors so that all of them can be
+// reported at once instead of stopping at the first one. It is safe for
+// concurrent use.
+type Diagnostics struct {
+	fset *token.FileSet
+
+	mu   sync.Mutex
+	list []Diagnostic
+}
+
+// NewDiagnostics creates an empty Diagnostics resolving positions in fset.
+func NewDiagnostics(fset *token.FileSet) *Diagnostics {
+	return &Diagnostics{fset: fset}
+}
+
+// Addf records a diagnostic at pos.
+func (d *Diagnostics) Addf(pos token.Pos, format string, args ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.list = append(d.list, Diagnostic{
+		Pos:     d.fset.Position(pos),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Len returns the number of diagnostics recorded.
+func (d *Diagnostics) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.list)
+}
+
+// List returns the diagnostics recorded.
+func (d *Diagnostics) List() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Diagnostic(nil), d.list...)
+}
+
+// jsonDiagnostic is the -json-diagnostics form of a Diagnostic
+type jsonDiagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// Print writes the diagnostics to w in source order, one per line, or as a
+// JSON array if asJSON is set.
+func (d *Diagnostics) Print(w io.Writer, asJSON bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sort.SliceStable(d.list, func(i, j int) bool {
+		a, b := d.list[i].Pos, d.list[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	if asJSON {
+		out := make([]jsonDiagnostic, 0, len(d.list))
+		for _, diag := range d.list {
+			out = append(out, jsonDiagnostic{
+				File:    diag.Pos.Filename,
+				Line:    diag.Pos.Line,
+				Column:  diag.Pos.Column,
+				Message: diag.Message,
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	for _, diag := range d.list {
+		if _, err := fmt.Fprintln(w, diag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
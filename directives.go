@@ -0,0 +1,66 @@
+package main
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ecordell/optgen/internal/directive"
+)
+
+// StructSpec is a struct to generate options for, along with the settings
+// that apply to it after per-struct overrides.
+type StructSpec struct {
+	File     *ast.File
+	Spec     *ast.TypeSpec
+	Settings Settings
+
+	// Output is the path the struct's options are written to
+	Output string
+
+	// Package is the package name of the output, or empty to infer it from
+	// the output directory
+	Package string
+}
+
+// newStructSpec creates the StructSpec for a struct found in file, applying the
+// arguments of its //optgen:generate directive, if any. Invalid arguments are
+// reported to the run's diagnostics. srcPath is the path of the file, which
+// output= arguments are relative to.
+func newStructSpec(file *ast.File, ts *ast.TypeSpec, srcPath string, settings Settings, output string) StructSpec {
+	spec := StructSpec{File: file, Spec: ts, Settings: settings, Output: output}
+
+	for _, d := range directive.ForType(file, ts) {
+		if d.Name != directive.Generate {
+			continue
+		}
+		for _, arg := range strings.Fields(d.Args) {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				settings.Diags.Addf(d.Comment.Pos(), "invalid %s%s argument %q on type %s: must be key=value", directive.Prefix, directive.Generate, arg, ts.Name.Name)
+				continue
+			}
+			switch key {
+			case "prefix":
+				usePrefix, err := strconv.ParseBool(value)
+				if err != nil {
+					settings.Diags.Addf(d.Comment.Pos(), "invalid %s%s prefix %q on type %s: must be true or false", directive.Prefix, directive.Generate, value, ts.Name.Name)
+					continue
+				}
+				spec.Settings.UsePrefix = usePrefix
+			case "output":
+				spec.Output = filepath.Join(filepath.Dir(srcPath), value)
+			default:
+				settings.Diags.Addf(d.Comment.Pos(), "unknown %s%s argument %q on type %s", directive.Prefix, directive.Generate, key, ts.Name.Name)
+			}
+		}
+	}
+
+	// Without -output or an output= argument, options are written next to the
+	// struct's source file, e.g. config.go -> config_options.go
+	if spec.Output == "" {
+		spec.Output = strings.TrimSuffix(srcPath, ".go") + "_options.go"
+	}
+	return spec
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+// docFormats are the output formats of the docs command
+var docFormats = []string{"markdown", "html"}
+
+// structDoc is the reference of the options generated for a struct
+type structDoc struct {
+	Name string
+	Doc  string
+
+	// OptType and the constructors are the generated names that apply options
+	OptType      string
+	Constructors []string
+
+	Options []optionDoc
+}
+
+// optionDoc is the reference of one generated option function
+type optionDoc struct {
+	Name   string
+	Field  string
+	Params string
+
+	// Effect is what the option does to the field: "sets", "appends to",
+	// "adds an entry to" or "replaces"
+	Effect string
+
+	Default     string
+	Sensitivity string
+	Description string
+
+	// AliasOf is the option that the option of a renamed field's former name
+	// forwards to, or ""
+	AliasOf string
+
+	// Deprecated is the message of the option's Deprecated: paragraph, or ""
+	Deprecated string
+}
+
+// docSensitivity describes how DebugMap shows each debugmap tag value
+var docSensitivity = map[string]string{
+	debugmap.Visible:       "visible",
+	debugmap.VisibleFormat: "visible",
+	debugmap.Sensitive:     "sensitive",
+	debugmap.URLRedacted:   "URL credentials redacted",
+	debugmap.Hidden:        "hidden",
+}
+
+// newStructDoc returns the reference of the options generated for a struct,
+// following the same fields and names as writeAllWithOptFuncsAST.
+func newStructDoc(s packageStruct) structDoc {
+	structName := s.spec.Name.Name
+	c := newConfig(structName, s.settings)
+	doc := structDoc{
+		Name:    structName,
+		Doc:     typeDoc(s.file, s.spec),
+		OptType: c.OptTypeName,
+		Constructors: []string{
+			fmt.Sprintf("New%sWithOptions", c.TargetTypeName),
+			fmt.Sprintf("New%sWithOptionsAndDefaults", c.TargetTypeName),
+			fmt.Sprintf("%sWithOptions", c.TargetTypeName),
+			fmt.Sprintf("(*%s).WithOptions", structName),
+		},
+	}
+
+	st := s.spec.Type.(*ast.StructType)
+	for _, field := range st.Fields.List {
+		if field.Names == nil || unsupportedTypeAST(field.Type) != nil {
+			continue
+		}
+		opts, err := parseOptgenTag(field)
+		if err != nil {
+			// Generating reports the invalid tag
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			fieldName := name.Name
+			base := optionDoc{
+				Field:       fieldName,
+				Default:     fieldDefault(field),
+				Sensitivity: fieldSensitivity(field, fieldName, s),
+				Description: strings.Join(strings.Fields(fieldDoc(field)), " "),
+			}
+
+			// options returns the rows of the options generated for the field
+			// under optName, its own name or an alias, with params named like
+			// writeAliasOptsAST names them
+			options := func(optName string) []optionDoc {
+				option := func(verb, effect, params string) optionDoc {
+					o := base
+					o.Name = c.optionFuncName(verb, optName)
+					o.Effect = effect
+					o.Params = params
+					return o
+				}
+				param := func(t ast.Expr) string {
+					return unexport(optName) + " " + types.ExprString(t)
+				}
+				switch fieldType := field.Type.(type) {
+				case *ast.ArrayType:
+					return []optionDoc{
+						option("With", "appends to", param(fieldType.Elt)),
+						option("Set", "replaces", param(field.Type)),
+					}
+				case *ast.MapType:
+					return []optionDoc{
+						option("With", "adds an entry to", fmt.Sprintf("key %s, value %s", types.ExprString(fieldType.Key), types.ExprString(fieldType.Value))),
+						option("Set", "replaces", param(field.Type)),
+					}
+				default:
+					return []optionDoc{option("With", "sets", param(field.Type))}
+				}
+			}
+
+			own := options(fieldName)
+			if opts.deprecated != "" && len(opts.aliases) == 0 && !hasDeprecatedParagraph(fieldDoc(field)) {
+				for i := range own {
+					own[i].Deprecated = opts.deprecated
+				}
+			}
+			doc.Options = append(doc.Options, own...)
+
+			// The options of a renamed field's former names forward to its
+			// options
+			if len(field.Names) != 1 {
+				continue
+			}
+			aliases := optionAliases(fieldName, field, opts, c)
+			for _, alias := range opts.aliases {
+				for _, o := range options(alias) {
+					o.AliasOf = aliases[o.Name]
+					o.Description = ""
+					o.Deprecated = opts.deprecated
+					if o.Deprecated == "" {
+						o.Deprecated = fmt.Sprintf("use %s instead.", o.AliasOf)
+					}
+					doc.Options = append(doc.Options, o)
+				}
+			}
+		}
+	}
+	return doc
+}
+
+// hasDeprecatedParagraph reports whether a doc comment has a Deprecated:
+// paragraph of its own, which writeOptDocAST keeps instead of the deprecated
+// message of the optgen tag.
+func hasDeprecatedParagraph(doc string) bool {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldDefault returns the value of a field's default tag, or "".
+func fieldDefault(field *ast.Field) string {
+	value, err := debugmap.LookupTag(field, DefaultFieldTag)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// fieldSensitivity describes how DebugMap shows the named field of a struct,
+// with the same sensitivity rules as generating. It returns "" if the tag is
+// missing or invalid, which generating reports.
+func fieldSensitivity(field *ast.Field, fieldName string, s packageStruct) string {
+	return docSensitivity[debugMapValue(field, fieldName, s)]
+}
+
+// markdownCell escapes a code span for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// markdownText escapes prose for a Markdown table cell, so that doc comments
+// mentioning e.g. <name> aren't read as HTML.
+func markdownText(s string) string {
+	return strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+var markdownDocTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"cell": markdownCell,
+	"text": markdownText,
+}).Parse(`{{range $i, $s := .}}{{if $i}}
+{{end}}## {{$s.Name}}
+{{with $s.Doc}}
+{{text .}}
+{{end}}
+Options are of type ` + "`{{$s.OptType}}`" + ` and are applied with {{range $j, $c := $s.Constructors}}{{if $j}}, {{end}}` + "`{{$c}}`" + `{{end}}.
+{{if $s.Options}}
+| Option | Parameters | Effect | Default | DebugMap | Description |
+| --- | --- | --- | --- | --- | --- |
+{{range $o := $s.Options}}| ` + "`{{.Name}}`" + ` | ` + "`{{cell .Params}}`" + ` | {{.Effect}} ` + "`{{.Field}}`" + ` | {{with .Default}}` + "`{{cell .}}`" + `{{end}} | {{.Sensitivity}} | {{text .Description}}` +
+	`{{with .AliasOf}}{{if $o.Description}} {{end}}Alias of ` + "`{{.}}`" + `.{{end}}` +
+	`{{with .Deprecated}}{{if or $o.Description $o.AliasOf}} {{end}}**Deprecated:** {{text .}}{{end}} |
+{{end}}{{end}}{{end}}`))
+
+var htmlDocTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`{{range .}}<section id="{{.Name}}">
+<h2>{{.Name}}</h2>
+{{with .Doc}}<p>{{.}}</p>
+{{end}}<p>Options are of type <code>{{.OptType}}</code> and are applied with {{range $j, $c := .Constructors}}{{if $j}}, {{end}}<code>{{$c}}</code>{{end}}.</p>
+{{if .Options}}<table>
+<thead>
+<tr><th>Option</th><th>Parameters</th><th>Effect</th><th>Default</th><th>DebugMap</th><th>Description</th></tr>
+</thead>
+<tbody>
+{{range $o := .Options}}<tr><td><code>{{.Name}}</code></td><td><code>{{.Params}}</code></td><td>{{.Effect}} <code>{{.Field}}</code></td><td>{{with .Default}}<code>{{.}}</code>{{end}}</td><td>{{.Sensitivity}}</td><td>{{.Description}}` +
+	`{{with .AliasOf}}{{if $o.Description}} {{end}}Alias of <code>{{.}}</code>.{{end}}` +
+	`{{with .Deprecated}}{{if or $o.Description $o.AliasOf}} {{end}}<strong>Deprecated:</strong> {{.}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}</section>
+{{end}}`))
+
+// runDocs prints a reference of the options generated for each of the named
+// structs.
+func runDocs(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	formatFlag := fs.String(
+		"format",
+		"markdown",
+		"Output format: markdown or html",
+	)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 2 {
+		cmd.usageError(fs, "must specify a package directory and the structs to document")
+	}
+	if !contains(docFormats, *formatFlag) {
+		cmd.usageError(fs, "invalid -format %q: must be one of %s", *formatFlag, strings.Join(docFormats, ", "))
+	}
+
+	structs, _, err := loadPackageStructs(fs.Arg(0), flagConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	docs := make([]structDoc, 0, fs.NArg()-1)
+	for _, structName := range fs.Args()[1:] {
+		s, ok := structs[structName]
+		if !ok {
+			log.Fatalf("struct %s not found in %s", structName, fs.Arg(0))
+		}
+		if !s.settings.emits(EmitterOptions) {
+			log.Fatalf("no options are generated for %s: its emitters don't include %s", structName, EmitterOptions)
+		}
+		docs = append(docs, newStructDoc(s))
+	}
+
+	var out bytes.Buffer
+	if *formatFlag == "html" {
+		err = htmlDocTemplate.Execute(&out, docs)
+	} else {
+		err = markdownDocTemplate.Execute(&out, docs)
+	}
+	if err != nil {
+		log.Fatalf("couldn't write the docs: %v", err)
+	}
+	_, _ = os.Stdout.Write(out.Bytes())
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+const (
+	// EnvFieldTag is the struct tag that names a field's environment variable
+	// in the generated XOptionsFromEnv, e.g. `env:"PORT"`
+	EnvFieldTag = "env"
+
+	// envListSeparator separates the items of slice and map variables, and
+	// envKeyValueSeparator the keys and values of map items
+	envListSeparator     = ","
+	envKeyValueSeparator = "="
+)
+
+// scalarType describes a field (or element) type that is parsed from an
+// environment variable or converted from a decoded config value
+type scalarType struct {
+	// kind is the parser: string, bool, int, uint, float or duration
+	kind string
+
+	// bits is the bit size passed to strconv
+	bits int
+
+	// typ is the field type the parsed value is converted to, if convert
+	typ     ast.Expr
+	convert bool
+}
+
+// scalarParseResults are the types that each kind of parser returns
+var scalarParseResults = map[string]string{
+	"string":   "string",
+	"bool":     "bool",
+	"int":      "int64",
+	"uint":     "uint64",
+	"float":    "float64",
+	"duration": "time.Duration",
+}
+
+// basicScalars are the parsers and bit sizes of the basic types
+var basicScalars = map[types.BasicKind]scalarType{
+	types.String:  {kind: "string"},
+	types.Bool:    {kind: "bool"},
+	types.Int:     {kind: "int", bits: 0},
+	types.Int8:    {kind: "int", bits: 8},
+	types.Int16:   {kind: "int", bits: 16},
+	types.Int32:   {kind: "int", bits: 32},
+	types.Int64:   {kind: "int", bits: 64},
+	types.Uint:    {kind: "uint", bits: 0},
+	types.Uint8:   {kind: "uint", bits: 8},
+	types.Uint16:  {kind: "uint", bits: 16},
+	types.Uint32:  {kind: "uint", bits: 32},
+	types.Uint64:  {kind: "uint", bits: 64},
+	types.Float32: {kind: "float", bits: 32},
+	types.Float64: {kind: "float", bits: 64},
+}
+
+// scalarOf returns the parser for a field (element) type. t is its type
+// if the package could be type-checked; without it only the predeclared
+// types and time.Duration are recognized.
+func scalarOf(expr ast.Expr, t types.Type, resolver *ImportResolver) (scalarType, bool) {
+	var scalar scalarType
+	switch {
+	case isDurationAST(expr, resolver):
+		scalar = scalarType{kind: "duration"}
+	case t != nil:
+		basic, ok := t.Underlying().(*types.Basic)
+		if !ok {
+			return scalarType{}, false
+		}
+		if scalar, ok = basicScalars[basic.Kind()]; !ok {
+			return scalarType{}, false
+		}
+	default:
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return scalarType{}, false
+		}
+		basic, ok := types.Universe.Lookup(ident.Name).(*types.TypeName)
+		if !ok {
+			return scalarType{}, false
+		}
+		if scalar, ok = basicScalars[basic.Type().Underlying().(*types.Basic).Kind()]; !ok {
+			return scalarType{}, false
+		}
+	}
+	scalar.typ = expr
+	scalar.convert = types.ExprString(expr) != scalarParseResults[scalar.kind]
+	return scalar, true
+}
+
+// isDurationAST reports whether expr is time.Duration.
+func isDurationAST(expr ast.Expr, resolver *ImportResolver) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && resolver.Resolve(pkg.Name) == "time" && sel.Sel.Name == "Duration"
+}
+
+// parse generates the statements that parse src, returning an error naming
+// the variable envName if that fails, and returns the parsed value converted
+// to the field type. Parsers that can fail store their result in dst.
+func (s scalarType) parse(grp *jen.Group, dst string, src jen.Code, envName jen.Code, resolver *ImportResolver) jen.Code {
+	var call jen.Code
+	switch s.kind {
+	case "string":
+		return s.value(src, resolver)
+	case "bool":
+		call = jen.Qual("strconv", "ParseBool").Call(src)
+	case "int":
+		call = jen.Qual("strconv", "ParseInt").Call(src, jen.Lit(10), jen.Lit(s.bits))
+	case "uint":
+		call = jen.Qual("strconv", "ParseUint").Call(src, jen.Lit(10), jen.Lit(s.bits))
+	case "float":
+		call = jen.Qual("strconv", "ParseFloat").Call(src, jen.Lit(s.bits))
+	case "duration":
+		call = jen.Qual("time", "ParseDuration").Call(src)
+	}
+	grp.List(jen.Id(dst), jen.Err()).Op(":=").Add(call)
+	grp.If(jen.Err().Op("!=").Nil()).Block(
+		jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("invalid %s: %w"), envName, jen.Err())),
+	)
+	return s.value(jen.Id(dst), resolver)
+}
+
+// value returns a parsed value converted to the field type.
+func (s scalarType) value(parsed jen.Code, resolver *ImportResolver) jen.Code {
+	if !s.convert {
+		return parsed
+	}
+	return jen.Add(astTypeToJenCode(s.typ, resolver)).Call(parsed)
+}
+
+// envFieldName returns the environment variable name of a field without the
+// prefix: its env tag, or its name in UPPER_SNAKE_CASE.
+func envFieldName(tag, fieldName string) string {
+	if tag != "" {
+		return tag
+	}
+	return strings.ToUpper(toSnake(fieldName))
+}
+
+// nestedStructAST returns the name of the same-package struct type of a
+// field, or "" if it isn't one. t is the field type if the package could be
+// type-checked; without it only structs declared in file are recognized.
+func nestedStructAST(expr ast.Expr, t types.Type, file *ast.File) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if t != nil {
+		named, ok := t.(*types.Named)
+		if !ok || named.TypeArgs().Len() > 0 {
+			return ""
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return ""
+		}
+		return ident.Name
+	}
+	for _, ts := range allStructDefsAST(file) {
+		if ts.Name.Name == ident.Name && ts.TypeParams == nil {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// writeOptionsFromEnvAST generates XOptionsFromEnv, which returns an option for
+// each exported field whose environment variable is set. Fields tagged
+// `env:"-"` are skipped; other fields of unsupported types are reported.
+func writeOptionsFromEnvAST(buf *jen.File, st *ast.StructType, file *ast.File, c Config, rules *debugmap.Rules, resolver *ImportResolver) {
+	newFuncName := fmt.Sprintf("%sOptionsFromEnv", c.TargetTypeName)
+	receiver := jen.Id(c.ReceiverId).Op("*").Add(c.StructRef...)
+
+	buf.Comment(fmt.Sprintf("%s returns options for the fields of %s whose environment", newFuncName, c.StructName))
+	buf.Comment("variables are set, named prefix followed by the field's env tag or its name in")
+	buf.Comment("UPPER_SNAKE_CASE. Slices and maps are comma-separated, with key=value map items,")
+	buf.Comment("and nested structs use the field's name and \"_\" as an extended prefix. Unset")
+	buf.Comment("variables produce no option, so defaults are kept.")
+	buf.Func().Id(newFuncName).Params(jen.Id("prefix").String()).Params(jen.Index().Id(c.OptTypeName), jen.Error()).BlockFunc(func(grp *jen.Group) {
+		grp.Id("opts").Op(":=").Make(jen.Index().Id(c.OptTypeName), jen.Lit(0))
+
+		for _, field := range st.Fields.List {
+			tag, err := debugmap.LookupTag(field, EnvFieldTag)
+			if errors.Is(err, debugmap.ErrMissingTag) {
+				tag, err = "", nil
+			}
+			if tag == "-" {
+				continue
+			}
+
+			for _, name := range field.Names {
+				if !name.IsExported() {
+					continue
+				}
+				if err != nil {
+					c.Diags.Addf(field.Tag.Pos(), "invalid struct tag on field %s in type %s: %v", name.Name, c.TargetTypeName, err)
+					continue
+				}
+
+				fieldType := rules.FieldType(c.StructName, name.Name)
+				if !writeEnvField(grp, field.Type, fieldType, name.Name, envFieldName(tag, name.Name), file, c, receiver, resolver) {
+					c.Diags.Addf(field.Type.Pos(), "unsupported type %s for environment variable on field %s in type %s: tag it `env:\"-\"` to skip it", types.ExprString(field.Type), name.Name, c.TargetTypeName)
+				}
+			}
+		}
+
+		grp.Return(jen.Id("opts"), jen.Nil())
+	})
+}
+
+// writeEnvField generates the code that adds an option for one field if its
+// variable is set. It returns false if the field type isn't supported.
+func writeEnvField(grp *jen.Group, expr ast.Expr, t types.Type, fieldName, varName string, file *ast.File, c Config, receiver jen.Code, resolver *ImportResolver) bool {
+	field := jen.Id(c.ReceiverId).Dot(fieldName)
+	envName := jen.Id("prefix").Op("+").Lit(varName)
+	setField := func(value jen.Code) jen.Code {
+		return jen.Id("opts").Op("=").Append(jen.Id("opts"), jen.Func().Params(receiver).Block(
+			jen.Add(field).Op("=").Add(value),
+		))
+	}
+	lookup := jen.List(jen.Id("value"), jen.Id("ok")).Op(":=").Qual("os", "LookupEnv").Call(envName)
+
+	// Pointers are set to a parsed value, nested structs below
+	pointerExpr, pointerType := expr, t
+	if star, ok := expr.(*ast.StarExpr); ok {
+		pointerExpr = star.X
+		if ptr, ok := t.(*types.Pointer); ok {
+			pointerType = ptr.Elem()
+		}
+	}
+
+	if nested := nestedStructAST(pointerExpr, pointerType, file); nested != "" {
+		nestedFuncName := toTitle(nested) + "OptionsFromEnv"
+		if !c.nestedGenerated(nested, EmitterEnv, nestedFuncName) {
+			c.Diags.Addf(expr.Pos(), "nested struct %s of field %s in type %s has no %s: generate %s with the %s emitter too, or tag the field `env:\"-\"`", nested, fieldName, c.TargetTypeName, nestedFuncName, nested, EmitterEnv)
+			return true
+		}
+
+		// Nested structs are only read if a variable with their prefix is
+		// set, which also ends the recursion of self-referential structs
+		nestedPrefix := jen.Id("prefix").Op("+").Lit(varName + "_")
+		nestedOpts := strings.ToLower(fieldName[:1]) + fieldName[1:] + "Opts"
+		grp.If(jen.Qual("slices", "ContainsFunc").Call(
+			jen.Qual("os", "Environ").Call(),
+			jen.Func().Params(jen.Id("kv").String()).Bool().Block(
+				jen.Return(jen.Qual("strings", "HasPrefix").Call(jen.Id("kv"), nestedPrefix)),
+			),
+		)).Block(
+			jen.List(jen.Id(nestedOpts), jen.Err()).Op(":=").Id(nestedFuncName).Call(nestedPrefix),
+			jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+			jen.If(jen.Len(jen.Id(nestedOpts)).Op(">").Lit(0)).Block(
+				applyNestedOpts(receiver, field, nested, pointerExpr != expr, nestedOpts),
+			),
+		)
+		return true
+	}
+
+	if scalar, ok := scalarOf(pointerExpr, pointerType, resolver); ok {
+		grp.If(lookup, jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+			parsed := scalar.parse(ifGrp, "parsed", jen.Id("value"), envName, resolver)
+			if pointerExpr != expr {
+				ifGrp.Id("fieldValue").Op(":=").Add(parsed)
+				ifGrp.Add(setField(jen.Op("&").Id("fieldValue")))
+				return
+			}
+			ifGrp.Add(setField(parsed))
+		})
+		return true
+	}
+
+	if pointerExpr != expr {
+		return false
+	}
+	items := func(ifGrp *jen.Group) {
+		ifGrp.Id("items").Op(":=").Qual("strings", "Split").Call(jen.Id("value"), jen.Lit(envListSeparator))
+		ifGrp.If(jen.Id("value").Op("==").Lit("")).Block(jen.Id("items").Op("=").Nil())
+	}
+
+	switch fieldType := expr.(type) {
+	case *ast.ArrayType:
+		if fieldType.Len != nil {
+			return false
+		}
+		var elemType types.Type
+		if slice, ok := underlying(t).(*types.Slice); ok {
+			elemType = slice.Elem()
+		}
+		elem, ok := scalarOf(fieldType.Elt, elemType, resolver)
+		if !ok {
+			return false
+		}
+		grp.If(lookup, jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+			items(ifGrp)
+			ifGrp.Id("parsed").Op(":=").Make(astTypeToJenCode(expr, resolver), jen.Lit(0), jen.Len(jen.Id("items")))
+			ifGrp.For(jen.List(jen.Id("_"), jen.Id("item")).Op(":=").Range().Id("items")).BlockFunc(func(forGrp *jen.Group) {
+				parsedItem := elem.parse(forGrp, "parsedItem", jen.Qual("strings", "TrimSpace").Call(jen.Id("item")), envName, resolver)
+				forGrp.Id("parsed").Op("=").Append(jen.Id("parsed"), parsedItem)
+			})
+			ifGrp.Add(setField(jen.Id("parsed")))
+		})
+		return true
+
+	case *ast.MapType:
+		var keyType, valueType types.Type
+		if m, ok := underlying(t).(*types.Map); ok {
+			keyType, valueType = m.Key(), m.Elem()
+		}
+		key, ok := scalarOf(fieldType.Key, keyType, resolver)
+		if !ok {
+			return false
+		}
+		value, ok := scalarOf(fieldType.Value, valueType, resolver)
+		if !ok {
+			return false
+		}
+		grp.If(lookup, jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+			items(ifGrp)
+			ifGrp.Id("parsed").Op(":=").Make(astTypeToJenCode(expr, resolver), jen.Len(jen.Id("items")))
+			ifGrp.For(jen.List(jen.Id("_"), jen.Id("item")).Op(":=").Range().Id("items")).BlockFunc(func(forGrp *jen.Group) {
+				forGrp.List(jen.Id("itemKey"), jen.Id("itemValue"), jen.Id("ok")).Op(":=").Qual("strings", "Cut").Call(jen.Id("item"), jen.Lit(envKeyValueSeparator))
+				forGrp.If(jen.Op("!").Id("ok")).Block(
+					jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("invalid %s: %q is not a key"+envKeyValueSeparator+"value pair"), envName, jen.Id("item"))),
+				)
+				parsedKey := key.parse(forGrp, "parsedKey", jen.Qual("strings", "TrimSpace").Call(jen.Id("itemKey")), envName, resolver)
+				parsedValue := value.parse(forGrp, "parsedValue", jen.Qual("strings", "TrimSpace").Call(jen.Id("itemValue")), envName, resolver)
+				forGrp.Id("parsed").Index(parsedKey).Op("=").Add(parsedValue)
+			})
+			ifGrp.Add(setField(jen.Id("parsed")))
+		})
+		return true
+	}
+	return false
+}
+
+// applyNestedOpts returns the statement that adds an option applying the
+// options in the variable nestedOpts to a nested struct field, which is
+// allocated first if it's a nil pointer.
+func applyNestedOpts(receiver jen.Code, field *jen.Statement, nested string, pointer bool, nestedOpts string) jen.Code {
+	target := jen.Op("&").Add(field)
+	allocate := jen.Null()
+	if pointer {
+		target = field
+		allocate = jen.If(jen.Add(field).Op("==").Nil()).Block(
+			jen.Add(field).Op("=").Op("&").Id(nested).Values(),
+		)
+	}
+	return jen.Id("opts").Op("=").Append(jen.Id("opts"), jen.Func().Params(receiver).Block(
+		allocate,
+		jen.For(jen.List(jen.Id("_"), jen.Id("opt")).Op(":=").Range().Id(nestedOpts)).Block(
+			jen.Id("opt").Call(target),
+		),
+	))
+}
+
+// underlying returns the underlying type of t, or nil if t is nil.
+func underlying(t types.Type) types.Type {
+	if t == nil {
+		return nil
+	}
+	return t.Underlying()
+}
@@ -1,9 +1,11 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=9ed7fca14e568416 settings=b87bddb272460f59 body=abd2c44200c82e20
 package example
 
 import (
-	"fmt"
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 )
 
 type ConfigOption func(c *Config)
@@ -42,6 +44,22 @@ func (c *Config) ToOption() ConfigOption {
 
 // DebugMap returns a map form of Config for debugging
 func (c *Config) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Config for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *Config) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if c.Name == "" {
 		debugMap["Name"] = "(empty)"
@@ -75,21 +93,48 @@ func (c *Config) DebugMap() map[string]any {
 	if c.Metadata == nil {
 		debugMap["Metadata"] = "nil"
 	} else {
-		debugMap["Metadata"] = fmt.Sprintf("%v", c.Metadata)
+		debugMetadata := make(map[string]any, len(c.Metadata))
+		for k, v := range c.Metadata {
+			if dm, ok := any(&v).(interface {
+				debugMapWithState(int, map[uintptr]struct{}) any
+			}); ok {
+				debugMetadata[k] = dm.debugMapWithState(depth+1, seen)
+			} else if dm, ok := any(&v).(interface {
+				DebugMap() map[string]any
+			}); ok {
+				debugMetadata[k] = dm.DebugMap()
+			} else {
+				debugMetadata[k] = v
+			}
+		}
+		debugMap["Metadata"] = debugMetadata
 	}
 	debugMap["Debug"] = c.Debug
 	return debugMap
 }
 
 // FlatDebugMap returns a flattened map form of Config for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (c *Config) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -216,6 +261,22 @@ func (s *Server) ToOption() ServerOption {
 
 // DebugMap returns a map form of Server for debugging
 func (s *Server) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Server for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *Server) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if s.Host == "" {
 		debugMap["Host"] = "(empty)"
@@ -239,14 +300,27 @@ func (s *Server) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of Server for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (s *Server) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -297,6 +371,8 @@ func WithServerTLS(tLS bool) ServerOption {
 }
 
 // WithServerCert returns an option that can set Cert on a Server
+//
+// Cert is sensitive: DebugMap shows it as "(sensitive)".
 func WithServerCert(cert string) ServerOption {
 	return func(s *Server) {
 		s.Cert = cert
@@ -304,6 +380,8 @@ func WithServerCert(cert string) ServerOption {
 }
 
 // WithServerKey returns an option that can set Key on a Server
+//
+// Key is sensitive: DebugMap shows it as "(sensitive)".
 func WithServerKey(key string) ServerOption {
 	return func(s *Server) {
 		s.Key = key
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+const (
+	// FlagFieldTag is the struct tag that binds a field to a command line flag
+	// in the generated RegisterXFlags, e.g. `flag:"port,Port to listen on"`
+	FlagFieldTag = "flag"
+
+	// FlagPackageFlag and FlagPackagePflag are the values of the flag-package
+	// setting, which picks the flag package RegisterXFlags uses
+	FlagPackageFlag  = "flag"
+	FlagPackagePflag = "pflag"
+
+	pflagPkgPath = "github.com/spf13/pflag"
+)
+
+// flagPackages are the valid values of the flag-package setting
+var flagPackages = []string{FlagPackageFlag, FlagPackagePflag}
+
+// flagPkgPath returns the import path of a flag-package setting.
+func flagPkgPath(flagPackage string) string {
+	if flagPackage == FlagPackagePflag {
+		return pflagPkgPath
+	}
+	return "flag"
+}
+
+// flagField is a struct field bound to a flag
+type flagField struct {
+	fieldName string
+	flagName  string
+	usage     string
+
+	// method is the FlagSet method that defines the flag, e.g. "Duration"
+	method string
+
+	// pointer is set for pointer fields, which get a copy of the flag value
+	pointer bool
+}
+
+// flagMethods are the FlagSet methods for the field types that flags support
+var flagMethods = map[string]string{
+	"string":  "String",
+	"bool":    "Bool",
+	"int":     "Int",
+	"int64":   "Int64",
+	"uint":    "Uint",
+	"uint64":  "Uint64",
+	"float64": "Float64",
+}
+
+// flagStringSliceMethod marks []string fields, which stdlib flags collect
+// from repeated flags
+const flagStringSliceMethod = "StringSlice"
+
+// flagMethod returns the FlagSet method for a field type and whether the
+// field is a pointer, or "" if flags don't support the type.
+func flagMethod(expr ast.Expr, resolver *ImportResolver) (string, bool) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		method, pointer := flagMethod(t.X, resolver)
+		if pointer || method == flagStringSliceMethod {
+			return "", false
+		}
+		return method, method != ""
+	case *ast.Ident:
+		return flagMethods[t.Name], false
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && resolver.Resolve(pkg.Name) == "time" && t.Sel.Name == "Duration" {
+			return "Duration", false
+		}
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && elt.Name == "string" {
+			return flagStringSliceMethod, false
+		}
+	}
+	return "", false
+}
+
+// flagFields returns the fields of st with a flag tag. Invalid tags, unsupported
+// types and duplicate flag names are reported to the diagnostics.
+func flagFields(st *ast.StructType, c Config, resolver *ImportResolver) []flagField {
+	fields := make([]flagField, 0)
+	seen := make(map[string]string)
+	for _, field := range st.Fields.List {
+		tag, err := debugmap.LookupTag(field, FlagFieldTag)
+		if errors.Is(err, debugmap.ErrMissingTag) || tag == "-" {
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if err != nil {
+				c.Diags.Addf(field.Tag.Pos(), "invalid struct tag on field %s in type %s: %v", name.Name, c.TargetTypeName, err)
+				continue
+			}
+			method, pointer := flagMethod(field.Type, resolver)
+			if method == "" {
+				c.Diags.Addf(field.Type.Pos(), "unsupported type %s for flag on field %s in type %s: must be a string, bool, int, int64, uint, uint64, float64, time.Duration, a pointer to one of them, or []string", types.ExprString(field.Type), name.Name, c.TargetTypeName)
+				continue
+			}
+
+			flagName, usage, _ := strings.Cut(tag, ",")
+			if flagName == "" {
+				flagName = strings.ReplaceAll(toSnake(name.Name), "_", "-")
+			}
+			if other, ok := seen[flagName]; ok {
+				c.Diags.Addf(field.Tag.Pos(), "flag %q on field %s in type %s is already used by field %s", flagName, name.Name, c.TargetTypeName, other)
+				continue
+			}
+			seen[flagName] = name.Name
+
+			fields = append(fields, flagField{
+				fieldName: name.Name,
+				flagName:  flagName,
+				usage:     usage,
+				method:    method,
+				pointer:   pointer,
+			})
+		}
+	}
+	return fields
+}
+
+// writeRegisterFlagsAST generates RegisterXFlags, which defines a flag for each
+// field with a flag tag and returns a function that turns the flags that were
+// set into an option. Structs without flag tags get no RegisterXFlags.
+func writeRegisterFlagsAST(buf *jen.File, st *ast.StructType, c Config, flagPackage string, resolver *ImportResolver) {
+	fields := flagFields(st, c, resolver)
+	if len(fields) == 0 {
+		return
+	}
+
+	pkgPath := flagPkgPath(flagPackage)
+	newFuncName := fmt.Sprintf("Register%sFlags", c.TargetTypeName)
+	flagName := func(f flagField) *jen.Statement {
+		return jen.Id("prefix").Op("+").Lit(f.flagName)
+	}
+	localName := func(f flagField) string {
+		return "flag" + f.fieldName
+	}
+
+	buf.Comment(fmt.Sprintf("%s defines a flag on fs for each field of %s with a flag tag, with", newFuncName, c.StructName))
+	buf.Comment(fmt.Sprintf("prefix prepended to the flag names. The returned function returns a %s", c.OptTypeName))
+	buf.Comment("that sets only the fields whose flags were set, so it can be applied over defaults.")
+	buf.Func().Id(newFuncName).Params(
+		jen.Id("fs").Op("*").Qual(pkgPath, "FlagSet"),
+		jen.Id("prefix").String(),
+	).Func().Params().Id(c.OptTypeName).BlockFunc(func(grp *jen.Group) {
+		for _, f := range fields {
+			switch {
+			case f.method == flagStringSliceMethod && flagPackage == FlagPackageFlag:
+				// The flag package has no slice flags, so values are
+				// collected from repeated flags
+				grp.Id(localName(f)).Op(":=").New(jen.Index().String())
+				grp.Id("fs").Dot("Func").Call(flagName(f), jen.Lit(f.usage), jen.Func().Params(jen.Id("value").String()).Error().Block(
+					jen.Op("*").Id(localName(f)).Op("=").Append(jen.Op("*").Id(localName(f)), jen.Id("value")),
+					jen.Return(jen.Nil()),
+				))
+			default:
+				grp.Id(localName(f)).Op(":=").Id("fs").Dot(f.method).Call(flagName(f), flagZeroValue(f.method), jen.Lit(f.usage))
+			}
+		}
+
+		grp.Return(jen.Func().Params().Id(c.OptTypeName).BlockFunc(func(retGrp *jen.Group) {
+			retGrp.Id("set").Op(":=").Make(jen.Map(jen.String()).Bool())
+			retGrp.Id("fs").Dot("Visit").Call(jen.Func().Params(jen.Id("f").Op("*").Qual(pkgPath, "Flag")).Block(
+				jen.Id("set").Index(jen.Id("f").Dot("Name")).Op("=").True(),
+			))
+			retGrp.Return(jen.Func().Params(jen.Id(c.ReceiverId).Op("*").Add(c.StructRef...)).BlockFunc(func(optGrp *jen.Group) {
+				for _, f := range fields {
+					field := jen.Id(c.ReceiverId).Dot(f.fieldName)
+					optGrp.If(jen.Id("set").Index(flagName(f))).BlockFunc(func(ifGrp *jen.Group) {
+						if f.pointer {
+							// Copy the value so the struct doesn't share the
+							// flag's variable
+							ifGrp.Id("value").Op(":=").Op("*").Id(localName(f))
+							ifGrp.Add(field).Op("=").Op("&").Id("value")
+							return
+						}
+						ifGrp.Add(field).Op("=").Op("*").Id(localName(f))
+					})
+				}
+			}))
+		}))
+	})
+}
+
+// flagZeroValue returns the default value argument of a FlagSet method.
+func flagZeroValue(method string) jen.Code {
+	switch method {
+	case "String":
+		return jen.Lit("")
+	case "Bool":
+		return jen.False()
+	case "Float64":
+		return jen.Lit(0.0)
+	case flagStringSliceMethod:
+		return jen.Nil()
+	}
+	return jen.Lit(0)
+}
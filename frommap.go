@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+// decodePkgPath is the runtime package used by the generated XOptionsFromMap
+const decodePkgPath = "github.com/ecordell/optgen/decode"
+
+// documentTags are the struct tags that name a field's key in config
+// documents, in lookup order
+var documentTags = []string{"json", "yaml"}
+
+// decodeFuncs are the decode functions that convert each kind of scalar
+var decodeFuncs = map[string]string{
+	"string":   "String",
+	"bool":     "Bool",
+	"int":      "Int",
+	"uint":     "Uint",
+	"float":    "Float",
+	"duration": "Duration",
+}
+
+// documentKeys returns the keys that set a field in a config document: the
+// names in its json and yaml tags, or its name if neither tag names it. It
+// returns no keys if the tags skip the field with "-".
+func documentKeys(field *ast.Field, fieldName string) ([]string, error) {
+	keys := make([]string, 0, len(documentTags))
+	skipped := false
+	for _, key := range documentTags {
+		tag, err := debugmap.LookupTag(field, key)
+		if errors.Is(err, debugmap.ErrMissingTag) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		switch {
+		case name == "-":
+			skipped = true
+		case name != "" && !contains(keys, name):
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 && !skipped {
+		keys = append(keys, fieldName)
+	}
+	return keys, nil
+}
+
+// decodeFunc returns the decode function that converts a value to a scalar
+// field type, instantiated with the field type.
+func (s scalarType) decodeFunc(resolver *ImportResolver) *jen.Statement {
+	return jen.Qual(decodePkgPath, decodeFuncs[s.kind]).Types(astTypeToJenCode(s.typ, resolver))
+}
+
+// writeOptionsFromMapAST generates XOptionsFromMap, which returns an option for
+// each field set in a decoded config document, and the XOptionsFromJSON and
+// XOptionsFromYAML wrappers that decode a document first. Fields whose json
+// or yaml tag is "-" are skipped; other fields of unsupported types are
+// reported.
+func writeOptionsFromMapAST(buf *jen.File, st *ast.StructType, file *ast.File, c Config, rules *debugmap.Rules, resolver *ImportResolver) {
+	newFuncName := fmt.Sprintf("%sOptionsFromMap", c.TargetTypeName)
+	receiver := jen.Id(c.ReceiverId).Op("*").Add(c.StructRef...)
+
+	known := make([]jen.Code, 0)
+	fields := make([]jen.Code, 0)
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			keys, err := documentKeys(field, name.Name)
+			if err != nil {
+				c.Diags.Addf(field.Tag.Pos(), "invalid struct tag on field %s in type %s: %v", name.Name, c.TargetTypeName, err)
+				continue
+			}
+			if len(keys) == 0 {
+				continue
+			}
+
+			fieldType := rules.FieldType(c.StructName, name.Name)
+			code, ok := mapFieldCode(field.Type, fieldType, name.Name, keys, file, c, receiver, resolver)
+			if !ok {
+				c.Diags.Addf(field.Type.Pos(), "unsupported type %s for config documents on field %s in type %s: tag it `json:\"-\" yaml:\"-\"` to skip it", types.ExprString(field.Type), name.Name, c.TargetTypeName)
+				continue
+			}
+			for _, key := range keys {
+				known = append(known, jen.Lit(key))
+			}
+			fields = append(fields, code)
+		}
+	}
+
+	buf.Comment(fmt.Sprintf("%s returns options for the fields of %s that are set in m,", newFuncName, c.StructName))
+	buf.Comment("a decoded config document keyed by the fields' json or yaml tag names. Values")
+	buf.Comment("are converted strictly and keys that match no field are an error, so typos")
+	buf.Comment("aren't silently ignored. Fields missing from m produce no option, so defaults")
+	buf.Comment("are kept.")
+	buf.Func().Id(newFuncName).Params(jen.Id("m").Map(jen.String()).Any()).Params(jen.Index().Id(c.OptTypeName), jen.Error()).BlockFunc(func(grp *jen.Group) {
+		grp.If(jen.Err().Op(":=").Qual(decodePkgPath, "CheckKeys").Call(append([]jen.Code{jen.Id("m")}, known...)...), jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Err()),
+		)
+		grp.Id("opts").Op(":=").Make(jen.Index().Id(c.OptTypeName), jen.Lit(0), jen.Len(jen.Id("m")))
+		for _, code := range fields {
+			grp.Add(code)
+		}
+		grp.Return(jen.Id("opts"), jen.Nil())
+	})
+
+	for _, format := range []string{"JSON", "YAML"} {
+		funcName := fmt.Sprintf("%sOptionsFrom%s", c.TargetTypeName, format)
+		buf.Comment(fmt.Sprintf("%s decodes a %s document from r and returns its options, see", funcName, format))
+		buf.Comment(newFuncName + ".")
+		buf.Func().Id(funcName).Params(jen.Id("r").Qual("io", "Reader")).Params(jen.Index().Id(c.OptTypeName), jen.Error()).Block(
+			jen.List(jen.Id("m"), jen.Err()).Op(":=").Qual(decodePkgPath, format).Call(jen.Id("r")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+			jen.Return(jen.Id(newFuncName).Call(jen.Id("m"))),
+		)
+	}
+}
+
+// mapFieldCode returns the code that adds an option for one field if one of
+// its keys is set. It returns false if the field type isn't supported.
+func mapFieldCode(expr ast.Expr, t types.Type, fieldName string, keys []string, file *ast.File, c Config, receiver jen.Code, resolver *ImportResolver) (jen.Code, bool) {
+	field := jen.Id(c.ReceiverId).Dot(fieldName)
+	setField := func(value jen.Code) jen.Code {
+		return jen.Id("opts").Op("=").Append(jen.Id("opts"), jen.Func().Params(receiver).Block(
+			jen.Add(field).Op("=").Add(value),
+		))
+	}
+	lookupArgs := []jen.Code{jen.Id("m")}
+	for _, key := range keys {
+		lookupArgs = append(lookupArgs, jen.Lit(key))
+	}
+	lookup := jen.List(jen.Id("value"), jen.Id("key"), jen.Id("ok")).Op(":=").Qual(decodePkgPath, "Lookup").Call(lookupArgs...)
+	returnErr := jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err()))
+
+	// Pointers are set to a converted value, nested structs below
+	pointerExpr, pointerType := expr, t
+	if star, ok := expr.(*ast.StarExpr); ok {
+		pointerExpr = star.X
+		if ptr, ok := t.(*types.Pointer); ok {
+			pointerType = ptr.Elem()
+		}
+	}
+
+	if nested := nestedStructAST(pointerExpr, pointerType, file); nested != "" {
+		nestedFuncName := toTitle(nested) + "OptionsFromMap"
+		if !c.nestedGenerated(nested, EmitterMap, nestedFuncName) {
+			c.Diags.Addf(expr.Pos(), "nested struct %s of field %s in type %s has no %s: generate %s with the %s emitter too, or tag the field `json:\"-\" yaml:\"-\"`", nested, fieldName, c.TargetTypeName, nestedFuncName, nested, EmitterMap)
+			return jen.Null(), true
+		}
+		nestedOpts := strings.ToLower(fieldName[:1]) + fieldName[1:] + "Opts"
+		return jen.If(lookup, jen.Id("ok")).Block(
+			jen.List(jen.Id("nested"), jen.Err()).Op(":=").Qual(decodePkgPath, "Object").Call(jen.Id("key"), jen.Id("value")),
+			returnErr,
+			jen.List(jen.Id(nestedOpts), jen.Err()).Op(":=").Id(nestedFuncName).Call(jen.Id("nested")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Qual(decodePkgPath, "Within").Call(jen.Id("key"), jen.Err())),
+			),
+			applyNestedOpts(receiver, field, nested, pointerExpr != expr, nestedOpts),
+		), true
+	}
+
+	var convert jen.Code
+	if scalar, ok := scalarOf(pointerExpr, pointerType, resolver); ok {
+		convert = scalar.decodeFunc(resolver).Call(jen.Id("key"), jen.Id("value"))
+	} else if pointerExpr != expr {
+		return nil, false
+	} else {
+		switch fieldType := expr.(type) {
+		case *ast.ArrayType:
+			if fieldType.Len != nil {
+				return nil, false
+			}
+			var elemType types.Type
+			if slice, ok := underlying(t).(*types.Slice); ok {
+				elemType = slice.Elem()
+			}
+			elem, ok := scalarOf(fieldType.Elt, elemType, resolver)
+			if !ok {
+				return nil, false
+			}
+			convert = jen.Qual(decodePkgPath, "Slice").Call(jen.Id("key"), jen.Id("value"), elem.decodeFunc(resolver))
+
+		case *ast.MapType:
+			var keyType, valueType types.Type
+			if m, ok := underlying(t).(*types.Map); ok {
+				keyType, valueType = m.Key(), m.Elem()
+			}
+			key, ok := scalarOf(fieldType.Key, keyType, resolver)
+			if !ok || key.kind != "string" {
+				return nil, false
+			}
+			value, ok := scalarOf(fieldType.Value, valueType, resolver)
+			if !ok {
+				return nil, false
+			}
+			convert = jen.Qual(decodePkgPath, "Map").Types(astTypeToJenCode(fieldType.Key, resolver)).Call(jen.Id("key"), jen.Id("value"), value.decodeFunc(resolver))
+
+		default:
+			return nil, false
+		}
+	}
+
+	return jen.If(lookup, jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+		ifGrp.List(jen.Id("converted"), jen.Err()).Op(":=").Add(convert)
+		ifGrp.Add(returnErr)
+		if pointerExpr != expr {
+			ifGrp.Add(setField(jen.Op("&").Id("converted")))
+			return
+		}
+		ifGrp.Add(setField(jen.Id("converted")))
+	}), true
+}
@@ -6,11 +6,14 @@ require (
 	github.com/creasty/defaults v1.7.0
 	github.com/dave/jennifer v1.6.1
 	github.com/fatih/structtag v1.2.0
+	github.com/spf13/pflag v1.0.10
 	golang.org/x/tools v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	golang.org/x/mod v0.31.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
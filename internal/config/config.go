@@ -0,0 +1,205 @@
+// Package config reads optgen config files, which set generation options for
+// the packages below them and their structs. Both the optgen command and its
+// analyzer load them, so that they agree on the settings of every struct.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the config files optgen looks for, from the package directory
+// upward. The first one found is used.
+var FileNames = []string{"optgen.yaml", "optgen.yml", "optgen.json"}
+
+// File is the contents of an optgen.yaml or optgen.json file:
+//
+//	defaults:
+//	  sensitive-field-name-matches: [password, secret, token]
+//	packages:
+//	  internal/server:
+//	    prefix: true
+//	    structs:
+//	      Config:
+//	        output: config_options.go
+//	        emitters: [options]
+type File struct {
+	// Defaults apply to every package
+	Defaults Generate `json:"defaults" yaml:"defaults"`
+
+	// Packages override the defaults, keyed by the package directory
+	// relative to the config file ("." for the config file's directory)
+	Packages map[string]Package `json:"packages" yaml:"packages"`
+
+	// Path is the file the config was loaded from
+	Path string `json:"-" yaml:"-"`
+}
+
+// Package holds the settings of a package and its structs.
+type Package struct {
+	Generate `yaml:",inline"`
+
+	// Structs override the package settings, keyed by struct name
+	Structs map[string]Generate `json:"structs,omitempty" yaml:"structs,omitempty"`
+}
+
+// Generate holds generation settings at one level of configuration. Unset
+// (nil) fields inherit from the level below.
+type Generate struct {
+	// Output is the output file, relative to the package directory
+	Output *string `json:"output,omitempty" yaml:"output"`
+
+	// OutputPattern is a text/template for a separate output file per struct,
+	// relative to the package directory, with .Struct and .Package and the
+	// snake and lower functions, e.g. "{{.Struct | snake}}_options.go". Output
+	// and OutputPattern are alternatives: setting one overrides the other on
+	// lower levels.
+	OutputPattern *string `json:"output-pattern,omitempty" yaml:"output-pattern"`
+
+	Package *string `json:"package,omitempty" yaml:"package"`
+	Prefix  *bool   `json:"prefix,omitempty" yaml:"prefix"`
+
+	// OptionNameTemplate is a text/template for option function names, with
+	// .Verb ("With" or "Set"), .Struct and .Field, e.g.
+	// "{{.Verb}}{{.Struct}}{{.Field}}". It takes precedence over Prefix.
+	OptionNameTemplate *string `json:"option-name-template,omitempty" yaml:"option-name-template"`
+
+	// Emitters selects the code to generate
+	Emitters []string `json:"emitters,omitempty" yaml:"emitters"`
+
+	// FlagPackage is the flag package that RegisterXFlags uses: "flag" or
+	// "pflag" (github.com/spf13/pflag)
+	FlagPackage *string `json:"flag-package,omitempty" yaml:"flag-package"`
+
+	SensitiveFieldNameMatches []string `json:"sensitive-field-name-matches,omitempty" yaml:"sensitive-field-name-matches"`
+	SensitiveFieldNameAllow   []string `json:"sensitive-field-name-allow,omitempty" yaml:"sensitive-field-name-allow"`
+	SensitiveTypes            []string `json:"sensitive-types,omitempty" yaml:"sensitive-types"`
+	DebugMapDefault           *string  `json:"debugmap-default,omitempty" yaml:"debugmap-default"`
+	DebugMapMaxDepth          *int     `json:"debugmap-max-depth,omitempty" yaml:"debugmap-max-depth"`
+}
+
+// Merge returns c with the fields that are set in override replaced.
+func (c Generate) Merge(override Generate) Generate {
+	empty := ""
+	if override.Output != nil {
+		c.Output = override.Output
+		if override.OutputPattern == nil && *override.Output != "" {
+			c.OutputPattern = &empty
+		}
+	}
+	if override.OutputPattern != nil {
+		c.OutputPattern = override.OutputPattern
+		if override.Output == nil && *override.OutputPattern != "" {
+			c.Output = &empty
+		}
+	}
+	if override.Package != nil {
+		c.Package = override.Package
+	}
+	if override.Prefix != nil {
+		c.Prefix = override.Prefix
+	}
+	if override.OptionNameTemplate != nil {
+		c.OptionNameTemplate = override.OptionNameTemplate
+	}
+	if override.Emitters != nil {
+		c.Emitters = override.Emitters
+	}
+	if override.FlagPackage != nil {
+		c.FlagPackage = override.FlagPackage
+	}
+	if override.SensitiveFieldNameMatches != nil {
+		c.SensitiveFieldNameMatches = override.SensitiveFieldNameMatches
+	}
+	if override.SensitiveFieldNameAllow != nil {
+		c.SensitiveFieldNameAllow = override.SensitiveFieldNameAllow
+	}
+	if override.SensitiveTypes != nil {
+		c.SensitiveTypes = override.SensitiveTypes
+	}
+	if override.DebugMapDefault != nil {
+		c.DebugMapDefault = override.DebugMapDefault
+	}
+	if override.DebugMapMaxDepth != nil {
+		c.DebugMapMaxDepth = override.DebugMapMaxDepth
+	}
+	return c
+}
+
+// Load finds and parses the nearest config file in dir or one of its parents.
+// It returns nil if there is none.
+func Load(dir string) (*File, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		for _, name := range FileNames {
+			configPath := filepath.Join(dir, name)
+			data, err := os.ReadFile(configPath)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			file := &File{Path: configPath}
+			if filepath.Ext(name) == ".json" {
+				dec := json.NewDecoder(bytes.NewReader(data))
+				dec.DisallowUnknownFields()
+				err = dec.Decode(file)
+			} else {
+				dec := yaml.NewDecoder(bytes.NewReader(data))
+				dec.KnownFields(true)
+				err = dec.Decode(file)
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("parsing %s: %w", configPath, err)
+			}
+			return file, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ForPackage returns the settings of the package in pkgDir.
+func (f *File) ForPackage(pkgDir string) Package {
+	absDir, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return Package{}
+	}
+	rel, err := filepath.Rel(filepath.Dir(f.Path), absDir)
+	if err != nil {
+		return Package{}
+	}
+	rel = filepath.ToSlash(rel)
+	for key, pkg := range f.Packages {
+		if path.Clean(key) == rel {
+			return pkg
+		}
+	}
+	return Package{}
+}
+
+// ForStruct returns the settings that the file sets for the named struct of
+// the package in pkgDir: its defaults, overridden by the package's settings,
+// overridden by the struct's. An empty name returns the package's settings.
+func (f *File) ForStruct(pkgDir, name string) Generate {
+	pkg := f.ForPackage(pkgDir)
+	return Generate{}.Merge(f.Defaults).Merge(pkg.Generate).Merge(pkg.Structs[name])
+}
@@ -0,0 +1,193 @@
+package debugmap
+
+import (
+	"fmt"
+	"go/types"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultSensitiveNames is the curated set of field name patterns that are
+// considered sensitive unless -sensitive-field-name-matches is set.
+var DefaultSensitiveNames = "secure,password,secret,token,apikey,privatekey,credential,dsn"
+
+// SensitiveMarkerMethod is the method name of the marker interface
+// (interface{ Sensitive() }) that identifies types holding secrets.
+const SensitiveMarkerMethod = "Sensitive"
+
+// Rules decides which fields must be tagged as sensitive, based on
+// their names and, when type information is available, their types.
+type Rules struct {
+	// NameMatches match the names of sensitive fields
+	NameMatches []NamePattern
+
+	// AllowedNames match field names that look sensitive but are known not
+	// to be, exempting them from NameMatches
+	AllowedNames []NamePattern
+
+	// Types are qualified names (e.g. "crypto/tls.Certificate") of types
+	// that are always sensitive
+	Types map[string]struct{}
+
+	// Pkg is the type-checked package of the structs, or nil if it could
+	// not be loaded, in which case only field names are checked
+	Pkg *types.Package
+}
+
+// NewRules creates Rules from the flag or config file values.
+// It returns an error if any of the name patterns is invalid.
+func NewRules(nameMatches, allowedNames, sensitiveTypes []string) (*Rules, error) {
+	matches, err := ParseNamePatterns(nameMatches)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := ParseNamePatterns(allowedNames)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &Rules{
+		NameMatches:  matches,
+		AllowedNames: allowed,
+		Types:        make(map[string]struct{}, len(sensitiveTypes)),
+	}
+	for _, t := range sensitiveTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			rules.Types[t] = struct{}{}
+		}
+	}
+	return rules, nil
+}
+
+// NamePattern matches field names. Patterns prefixed with "re:" are regular
+// expressions matched as written, patterns containing any of "*?[" are globs
+// matched case-insensitively against the whole name, and anything else is a
+// case-insensitive substring match.
+type NamePattern struct {
+	raw       string
+	re        *regexp.Regexp
+	glob      string
+	substring string
+}
+
+// ParseNamePatterns parses a list of name patterns, skipping empty entries.
+func ParseNamePatterns(specs []string) ([]NamePattern, error) {
+	patterns := make([]NamePattern, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		p := NamePattern{raw: spec}
+		switch {
+		case strings.HasPrefix(spec, "re:"):
+			re, err := regexp.Compile(strings.TrimPrefix(spec, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid name pattern %q: %w", spec, err)
+			}
+			p.re = re
+		case strings.ContainsAny(spec, "*?["):
+			p.glob = strings.ToLower(spec)
+			if _, err := path.Match(p.glob, ""); err != nil {
+				return nil, fmt.Errorf("invalid name pattern %q: %w", spec, err)
+			}
+		default:
+			p.substring = strings.ToLower(spec)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// Match reports whether the field name matches the pattern.
+func (p NamePattern) Match(name string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(name)
+	case p.glob != "":
+		ok, _ := path.Match(p.glob, strings.ToLower(name))
+		return ok
+	default:
+		return strings.Contains(strings.ToLower(name), p.substring)
+	}
+}
+
+// String returns the pattern as it was written.
+func (p NamePattern) String() string {
+	return p.raw
+}
+
+// SensitiveName returns the pattern that marks the field name as sensitive,
+// if any and if the name isn't allowed.
+func (r *Rules) SensitiveName(fieldName string) (NamePattern, bool) {
+	for _, allowed := range r.AllowedNames {
+		if allowed.Match(fieldName) {
+			return NamePattern{}, false
+		}
+	}
+	for _, pattern := range r.NameMatches {
+		if pattern.Match(fieldName) {
+			return pattern, true
+		}
+	}
+	return NamePattern{}, false
+}
+
+// FieldType returns the type of the named field of the named struct, or nil
+// if type information isn't available.
+func (r *Rules) FieldType(structName, fieldName string) types.Type {
+	if r.Pkg == nil {
+		return nil
+	}
+	obj := r.Pkg.Scope().Lookup(structName)
+	if obj == nil {
+		return nil
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == fieldName {
+			return st.Field(i).Type()
+		}
+	}
+	return nil
+}
+
+// SensitiveType returns the name of the sensitive type held by t, looking
+// through pointers, slices, arrays and map values.
+func (r *Rules) SensitiveType(t types.Type) (string, bool) {
+	switch tt := t.(type) {
+	case *types.Pointer:
+		return r.SensitiveType(tt.Elem())
+	case *types.Slice:
+		return r.SensitiveType(tt.Elem())
+	case *types.Array:
+		return r.SensitiveType(tt.Elem())
+	case *types.Map:
+		return r.SensitiveType(tt.Elem())
+	case *types.Named:
+		name := tt.Obj().Name()
+		if tt.Obj().Pkg() != nil {
+			name = tt.Obj().Pkg().Path() + "." + name
+		}
+		if _, ok := r.Types[name]; ok {
+			return name, true
+		}
+		if implementsSensitiveMarker(tt) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// implementsSensitiveMarker reports whether t or *t has a Sensitive() method.
+func implementsSensitiveMarker(t types.Type) bool {
+	marker := types.NewInterfaceType([]*types.Func{
+		types.NewFunc(0, nil, SensitiveMarkerMethod, types.NewSignatureType(nil, nil, nil, nil, nil, false)),
+	}, nil).Complete()
+	return types.Implements(t, marker) || types.Implements(types.NewPointer(t), marker)
+}
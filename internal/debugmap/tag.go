@@ -0,0 +1,226 @@
+// Package debugmap holds the rules for debugmap struct tags that are shared
+// by the optgen generator and its analyzer.
+package debugmap
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structtag"
+)
+
+const (
+	// Tag is the struct tag key that sets a field's DebugMap policy
+	Tag = "debugmap"
+
+	// Values of the debugmap tag
+	Visible       = "visible"
+	VisibleFormat = "visible-format"
+	Sensitive     = "sensitive"
+	URLRedacted   = "url-redacted"
+	Hidden        = "hidden"
+
+	// DefaultError is the policy for untagged fields that reports them
+	// instead of falling back to a tag value
+	DefaultError = "error"
+
+	// AllowSensitiveNameOption is the debugmap tag option that acknowledges a
+	// field whose name looks sensitive but whose value isn't, e.g.
+	// `debugmap:"visible,allow-sensitive-name"`.
+	AllowSensitiveNameOption = "allow-sensitive-name"
+)
+
+// Defaults are the valid policies for fields without a debugmap tag
+var Defaults = []string{Hidden, Visible, Sensitive, DefaultError}
+
+// IsValidDefault reports whether policy is one of Defaults.
+func IsValidDefault(policy string) bool {
+	for _, valid := range Defaults {
+		if policy == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrMissingTag is returned by LookupTag when the field has no such tag
+var ErrMissingTag = errors.New("missing tag")
+
+// LookupTag returns the value of the key in the struct tag of field.
+func LookupTag(field *ast.Field, key string) (string, error) {
+	if field.Tag == nil {
+		return "", ErrMissingTag
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", err
+	}
+	tags, err := structtag.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	tag, err := tags.Get(key)
+	if err != nil {
+		return "", ErrMissingTag
+	}
+	return tag.Value(), nil
+}
+
+// Problem is a mistake in a field's debugmap tag
+type Problem struct {
+	Pos     token.Pos
+	Message string
+
+	// Fix is the tag value that resolves the problem, or "" if there is no
+	// obvious one
+	Fix string
+}
+
+// FieldTag is the checked debugmap tag of a field
+type FieldTag struct {
+	// Value is the tag value without options, or "" if the tag is unusable
+	Value string
+
+	// Defaulted is set if the field is untagged and Value is the default
+	// policy
+	Defaulted bool
+
+	Problems []Problem
+}
+
+// CheckField checks the debugmap tag of the named field, falling back to
+// defaultPolicy if it is untagged. structName is the declared name of the
+// struct, used to look up field types, and typeName is its name in messages.
+// Whether url-redacted fields have a supported type is left to the caller.
+func CheckField(field *ast.Field, fieldName, structName, typeName string, rules *Rules, defaultPolicy string) FieldTag {
+	result := FieldTag{}
+
+	value, err := LookupTag(field, Tag)
+	if errors.Is(err, ErrMissingTag) && defaultPolicy != "" && defaultPolicy != DefaultError {
+		value, err = defaultPolicy, nil
+		result.Defaulted = true
+	}
+	if errors.Is(err, ErrMissingTag) {
+		result.Problems = append(result.Problems, Problem{
+			Pos:     field.Pos(),
+			Message: fmt.Sprintf("missing debugmap tag on field %s in type %s", fieldName, typeName),
+			Fix:     SuggestTag(field, structName, rules),
+		})
+		return result
+	}
+	if err != nil {
+		result.Problems = append(result.Problems, Problem{
+			Pos:     field.Tag.Pos(),
+			Message: fmt.Sprintf("invalid struct tag on field %s in type %s: %v", fieldName, typeName, err),
+		})
+		return result
+	}
+
+	// Split off tag options, e.g. "visible,allow-sensitive-name"
+	value, options, _ := strings.Cut(value, ",")
+	allowSensitiveName := false
+	if options != "" {
+		for _, option := range strings.Split(options, ",") {
+			if option != AllowSensitiveNameOption {
+				result.Problems = append(result.Problems, Problem{
+					Pos:     TagPos(field),
+					Message: fmt.Sprintf("unknown option '%s' for debugmap tag on field %s in type %s", option, fieldName, typeName),
+				})
+				return result
+			}
+			allowSensitiveName = true
+		}
+	}
+
+	switch value {
+	case Visible, VisibleFormat:
+		result.Problems = append(result.Problems, checkNotSensitive(field, fieldName, structName, typeName, rules, allowSensitiveName)...)
+	case Hidden, Sensitive, URLRedacted:
+	default:
+		result.Problems = append(result.Problems, Problem{
+			Pos:     TagPos(field),
+			Message: fmt.Sprintf("unknown value '%s' for debugmap tag on field %s in type %s", value, fieldName, typeName),
+		})
+		return result
+	}
+
+	result.Value = value
+	return result
+}
+
+// checkNotSensitive checks that a visible field's name doesn't match sensitive
+// patterns (unless allowName acknowledges it) and that its type isn't a
+// sensitive type.
+func checkNotSensitive(field *ast.Field, fieldName, structName, typeName string, rules *Rules, allowName bool) []Problem {
+	problems := make([]Problem, 0)
+	if pattern, ok := rules.SensitiveName(fieldName); ok && !allowName {
+		problems = append(problems, Problem{
+			Pos:     TagPos(field),
+			Message: fmt.Sprintf("field %s in type %s matches sensitive name pattern %q and must be marked as 'sensitive'", fieldName, typeName, pattern),
+			Fix:     Sensitive,
+		})
+	}
+
+	if fieldType := rules.FieldType(structName, fieldName); fieldType != nil {
+		if sensitiveName, ok := rules.SensitiveType(fieldType); ok {
+			problems = append(problems, Problem{
+				Pos:     TagPos(field),
+				Message: fmt.Sprintf("field %s in type %s has sensitive type %s and must be marked as 'sensitive'", fieldName, typeName, sensitiveName),
+				Fix:     Sensitive,
+			})
+		}
+	}
+	return problems
+}
+
+// SuggestTag returns the debugmap tag for an untagged field of the named
+// struct: "sensitive" for sensitive names or types, "visible-format" for
+// slices and maps, and "visible" otherwise.
+func SuggestTag(field *ast.Field, structName string, rules *Rules) string {
+	for _, name := range field.Names {
+		if _, ok := rules.SensitiveName(name.Name); ok {
+			return Sensitive
+		}
+		if _, ok := rules.SensitiveType(rules.FieldType(structName, name.Name)); ok {
+			return Sensitive
+		}
+	}
+	switch field.Type.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return VisibleFormat
+	}
+	return Visible
+}
+
+// SetTag returns the struct tag literal of field with key set to value,
+// keeping the tag's other keys, e.g. "`json:\"name\" debugmap:\"visible\"`".
+func SetTag(field *ast.Field, key, value string) (string, error) {
+	tags := &structtag.Tags{}
+	if field.Tag != nil {
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return "", err
+		}
+		tags, err = structtag.Parse(raw)
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := tags.Set(&structtag.Tag{Key: key, Name: value}); err != nil {
+		return "", err
+	}
+	return "`" + tags.String() + "`", nil
+}
+
+// TagPos returns the position of a field's tag, or of the field itself if it
+// is untagged, for reporting problems with its debugmap policy.
+func TagPos(field *ast.Field) token.Pos {
+	if field.Tag != nil {
+		return field.Tag.Pos()
+	}
+	return field.Pos()
+}
@@ -0,0 +1,90 @@
+// Package directive parses the //optgen: directive comments on struct types
+// that are shared by the optgen generator and its analyzer.
+package directive
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// Prefix starts optgen directive comments, e.g.
+// "//optgen:debugmap-default=hidden".
+const Prefix = "//optgen:"
+
+const (
+	// Generate marks a struct for generation by "optgen -all", e.g.
+	// "//optgen:generate prefix=true output=server_opts.go". Its arguments
+	// override the flags for that struct.
+	Generate = "generate"
+
+	// DebugMapDefault sets the policy for untagged fields of a struct
+	DebugMapDefault = "debugmap-default"
+)
+
+// Directive is an optgen directive comment from a type's doc comment.
+// "//optgen:debugmap-default=hidden" has Name "debugmap-default" and Value
+// "hidden"; anything after the first space is kept in Args.
+type Directive struct {
+	Name    string
+	Value   string
+	Args    string
+	Comment *ast.Comment
+}
+
+// Find returns the optgen directives in a doc comment.
+func Find(doc *ast.CommentGroup) []Directive {
+	if doc == nil {
+		return nil
+	}
+
+	directives := make([]Directive, 0)
+	for _, comment := range doc.List {
+		text, ok := strings.CutPrefix(comment.Text, Prefix)
+		if !ok {
+			continue
+		}
+		head, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+		name, value, _ := strings.Cut(head, "=")
+		directives = append(directives, Directive{
+			Name:    name,
+			Value:   value,
+			Args:    strings.TrimSpace(args),
+			Comment: comment,
+		})
+	}
+	return directives
+}
+
+// TypeSpecDoc returns the doc comment of a type spec. For the common
+// single-spec form "type X struct{...}" the comment is attached to the
+// enclosing declaration rather than the spec.
+func TypeSpecDoc(file *ast.File, ts *ast.TypeSpec) *ast.CommentGroup {
+	if ts.Doc != nil {
+		return ts.Doc
+	}
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || len(gd.Specs) != 1 {
+			continue
+		}
+		if gd.Specs[0] == ts {
+			return gd.Doc
+		}
+	}
+	return nil
+}
+
+// ForType returns the optgen directives in the doc comment of ts.
+func ForType(file *ast.File, ts *ast.TypeSpec) []Directive {
+	return Find(TypeSpecDoc(file, ts))
+}
+
+// Has reports whether the doc comment of ts contains the named directive.
+func Has(file *ast.File, ts *ast.TypeSpec, name string) bool {
+	for _, d := range ForType(file, ts) {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
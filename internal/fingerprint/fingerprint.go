@@ -0,0 +1,144 @@
+// Package fingerprint computes the hashes that optgen records in the header of
+// generated files, which tell an output that fell behind its structs apart
+// from one that was edited by hand.
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/ecordell/optgen/internal/directive"
+)
+
+// Header starts every file generated by optgen
+const Header = "// Code generated by github.com/ecordell/optgen. DO NOT EDIT."
+
+// hashesPrefix starts the header line that records the hashes, right after
+// Header
+const hashesPrefix = "// optgen hashes: "
+
+// Hashes are the hashes recorded in a generated file
+type Hashes struct {
+	// Source is the hash of the definitions of the structs in the file, see
+	// Source
+	Source string
+
+	// Settings is the hash of the generator settings for the structs
+	Settings string
+
+	// Body is the hash of the file after the header
+	Body string
+}
+
+// Line returns the header line that records h.
+func (h Hashes) Line() string {
+	return fmt.Sprintf("%ssource=%s settings=%s body=%s", hashesPrefix, h.Source, h.Settings, h.Body)
+}
+
+// Struct is a struct that a generated file was generated from
+type Struct struct {
+	File *ast.File
+	Spec *ast.TypeSpec
+}
+
+// Source returns the hash of the definitions of structs: their names, type
+// parameters, fields, tags and field comments, and their optgen directives.
+// Field comments count because the option docs are generated from them. Other
+// comments and formatting don't change it, nor does the order of structs.
+func Source(fset *token.FileSet, structs []Struct) string {
+	sorted := append([]Struct(nil), structs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Spec.Name.Name < sorted[j].Spec.Name.Name
+	})
+
+	var src bytes.Buffer
+	for _, s := range sorted {
+		// Printing the spec on its own leaves out its comments
+		if err := printer.Fprint(&src, fset, s.Spec); err != nil {
+			fmt.Fprintf(&src, "%s: %v", s.Spec.Name.Name, err)
+		}
+		src.WriteByte('\n')
+		ast.Inspect(s.Spec.Type, func(n ast.Node) bool {
+			if field, ok := n.(*ast.Field); ok {
+				src.WriteString(field.Doc.Text())
+				src.WriteString(field.Comment.Text())
+			}
+			return true
+		})
+		for _, d := range directive.ForType(s.File, s.Spec) {
+			src.WriteString(d.Comment.Text)
+			src.WriteByte('\n')
+		}
+	}
+	return hash(src.Bytes())
+}
+
+// Settings returns the hash of a description of the generator settings.
+func Settings(settings string) string {
+	return hash([]byte(settings))
+}
+
+// Stamp returns the generated file content, which starts with Header, with
+// the hashes line added after the header.
+func Stamp(content []byte, source, settings string) []byte {
+	body := bytes.TrimPrefix(content, []byte(Header+"\n"))
+	h := Hashes{Source: source, Settings: settings, Body: hash(body)}
+
+	stamped := make([]byte, 0, len(content)+len(h.Line())+1)
+	stamped = append(stamped, Header+"\n"+h.Line()+"\n"...)
+	return append(stamped, body...)
+}
+
+// Parse returns the hashes recorded in a generated file and whether it has
+// any. Files generated before optgen recorded hashes have none.
+func Parse(content []byte) (Hashes, bool) {
+	rest, ok := bytes.CutPrefix(content, []byte(Header+"\n"))
+	if !ok {
+		return Hashes{}, false
+	}
+	line, _, _ := bytes.Cut(rest, []byte("\n"))
+	return ParseLine(string(line))
+}
+
+// ParseLine parses the hashes line of a generated file.
+func ParseLine(line string) (Hashes, bool) {
+	fields, ok := strings.CutPrefix(line, hashesPrefix)
+	if !ok {
+		return Hashes{}, false
+	}
+	h := Hashes{}
+	for _, field := range strings.Fields(fields) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "source":
+			h.Source = value
+		case "settings":
+			h.Settings = value
+		case "body":
+			h.Body = value
+		}
+	}
+	return h, h.Source != "" && h.Body != ""
+}
+
+// Tampered reports whether the body of a generated file with hashes no
+// longer matches its recorded hash.
+func Tampered(content []byte, h Hashes) bool {
+	rest := bytes.TrimPrefix(content, []byte(Header+"\n"))
+	_, body, _ := bytes.Cut(rest, []byte("\n"))
+	return hash(body) != h.Body
+}
+
+// hash returns the first 16 hex digits of the SHA-256 of data, which is
+// plenty to notice changes.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
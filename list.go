@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+	"github.com/ecordell/optgen/internal/directive"
+)
+
+// runList prints the structs of the packages with the state of their debugmap
+// tags.
+func runList(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkgArgs := fs.Args()
+	if len(pkgArgs) == 0 {
+		pkgArgs = []string{"."}
+	}
+	packages, err := loadPackages(pkgArgs, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STRUCT\tTAGGED\tGENERATE\tSTATUS\tPOSITION")
+	for _, dir := range packages.Dirs {
+		layers, err := loadConfigLayers(dir, flagConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pkgs, err := packages.parsed(dir)
+		if err != nil {
+			log.Fatalf("%s: %v", dir, err)
+		}
+		pkgNames := make([]string, 0, len(pkgs))
+		for name := range pkgs {
+			pkgNames = append(pkgNames, name)
+		}
+		sort.Strings(pkgNames)
+
+		for _, pkgName := range pkgNames {
+			pkg := pkgs[pkgName]
+			for _, srcPath := range sortedFileNames(pkg) {
+				f := pkg.Files[srcPath]
+				for _, ts := range allStructDefsAST(f) {
+					status := debugMapTags(ts.Type.(*ast.StructType))
+					generate := "no"
+					if directive.Has(f, ts, directive.Generate) {
+						generate = "yes"
+					}
+					pos := packages.Fset.Position(ts.Pos())
+					fmt.Fprintf(tw, "%s.%s\t%d/%d\t%s\t%s\t%s:%d\n",
+						pkgName, ts.Name.Name,
+						status.Tagged, status.Total,
+						generate,
+						status.describe(structDebugMapDefault(f, ts, layers)),
+						pos.Filename, pos.Line,
+					)
+				}
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// structDebugMapDefault returns the policy for untagged fields of ts: its
+// //optgen:debugmap-default directive if valid, or the configured default.
+func structDebugMapDefault(file *ast.File, ts *ast.TypeSpec, layers ConfigLayers) string {
+	for _, d := range directive.ForType(file, ts) {
+		if d.Name == directive.DebugMapDefault && debugmap.IsValidDefault(d.Value) {
+			return d.Value
+		}
+	}
+	return *layers.Struct(ts.Name.Name).DebugMapDefault
+}
@@ -7,27 +7,110 @@
 //
 // Usage:
 //
-//	optgen [flags] <package-path> <struct-name> [<struct-name>...]
+//	optgen [generate] [flags] <package-path> <struct-name> [<struct-name>...]
+//	optgen [generate] [flags] -all <package-path> [<package-pattern>...]
+//	optgen [generate] [flags] -package <name> - <struct-name>...
+//	optgen check [flags] <package-path> <struct-name>...
+//	optgen list [flags] [<package-path>|<package-pattern>...]
+//	optgen init [flags] <package-path> <struct-name>...
+//	optgen tags [flags] [-dry-run] <package-path> <struct-name>...
+//	optgen schema [flags] [-output-dir <dir>] <package-path> <struct-name>...
+//	optgen docs [flags] [-format=markdown|html] <package-path> <struct-name>...
+//	optgen migrate [flags] [-dry-run] <package-path> [<package-pattern>...]
+//	optgen config print [flags] [<package-path>] [<struct-name>...]
+//	optgen version
+//
+// "generate" is the default command. "check" verifies that outputs are up to
+// date, "list" shows the structs of packages and the state of their debugmap
+// tags, "init" adds debugmap tags and a go:generate line to structs, and
+// "tags" only adds the tags, or prints them as a diff with -dry-run. "schema"
+// writes JSON Schema documents for the config document form of structs, and
+// "docs" prints a Markdown or HTML reference of their options. "migrate"
+// rewrites references to the options of renamed fields, kept as deprecated
+// aliases by optgen:"alias=..." tags, to their new names. Run
+// "optgen help <command>" for details.
+//
+// A first argument that names an existing directory is always a package
+// directory, even if it is also the name of a command, so "optgen docs" in a
+// directory with a docs subdirectory generates options for ./docs.
 //
 // Flags:
 //
 //	-output <path>
-//	    Location where generated options will be written (required)
+//	    Location where generated options will be written; it is only rewritten if the
+//	    generated code changed (default: next to each struct's file, e.g. config_options.go),
+//	    or "-" for stdout
+//	-output-pattern <template>
+//	    text/template for a separate output file per struct, relative to the package, using
+//	    .Struct, .Package and the snake and lower functions (e.g. "{{.Struct | snake}}_options.go")
+//	-all
+//	    Generate options for every struct annotated with an //optgen:generate directive
+//	-parallel <n>
+//	    Maximum number of packages to generate in parallel (default: GOMAXPROCS)
 //	-package <name>
 //	    Name of package to use in output file (optional, inferred from output directory)
-//	-sensitive-field-name-matches <substring>
-//	    Comma-separated list of field name substrings considered sensitive (default: "secure")
+//	-sensitive-field-name-matches <patterns>
+//	    Comma-separated list of field name patterns considered sensitive: case-insensitive
+//	    substrings, globs such as "*Key", or regexes prefixed with "re:" such as "re:Key$"
+//	    (default: "secure,password,secret,token,apikey,privatekey,credential,dsn")
+//	-sensitive-field-name-allow <patterns>
+//	    Comma-separated list of field name patterns exempt from the sensitive name check
+//	-sensitive-types <type>
+//	    Comma-separated list of qualified type names considered sensitive (e.g. "crypto/tls.Certificate")
+//	-debugmap-default <policy>
+//	    Policy for fields without a debugmap tag: hidden, visible, sensitive, or error (default: "error")
+//	-debugmap-max-depth <n>
+//	    Nesting depth past which DebugMap stops expanding nested structs (default: 10)
+//	-option-name-template <template>
+//	    text/template for option function names using .Verb, .Struct and .Field, overriding -prefix
+//	-emitters <list>
+//	    Comma-separated list of code to generate: options, debugmap, flags, env, map (default: "options,debugmap")
+//	-flag-package <name>
+//	    Flag package used by the flags emitter: flag or pflag (default: "flag")
+//	-check
+//	    Verify that the -output file is up to date without writing it, printing a diff and
+//	    exiting non-zero if it is not, or if -output-pattern would remove stale files. The
+//	    hashes in the output's header tell whether its structs or settings changed, or it
+//	    was edited by hand
+//	-json-diagnostics
+//	    Report generation errors as a JSON array instead of "file:line:col: message" lines
 //
 // Example:
 //
 //	//go:generate go run github.com/ecordell/optgen -output=config_options.go . Config
 //
+// Package arguments may be directories or go-style patterns such as ./... and
+// ./internal/..., which are loaded with a single go/packages load. -output
+// can't be used with multiple packages; their outputs default to the struct's
+// source file, or are set with directives or the config file.
+//
+// Settings can also be kept in an optgen.yaml or optgen.json file in the
+// package directory or one of its parents, with defaults, per-package and
+// per-struct sections. Explicitly set flags take precedence over the file, and
+// "optgen config print" shows the merged settings.
+//
+// A package argument of "-" reads a single Go file from stdin and writes its
+// options to stdout, e.g. for editor previews. It requires -package, and the
+// file is generated without type information.
+//
+// With -output-pattern, each struct gets its own output file. Files matching
+// the pattern that optgen generated for structs which no longer exist are
+// removed; files without its "Code generated" header are never removed.
+//
+// Instead of listing struct names, structs can be annotated for -all with a
+// directive in their doc comment. Its optional prefix= and output= arguments
+// override the flags for that struct:
+//
+//	//optgen:generate prefix=true output=server_options.go
+//	type Server struct { ... }
+//
 // Struct Tag Format:
 //
 // Fields must be annotated with the `debugmap` struct tag:
 //   - "visible" - Show actual field value in DebugMap
 //   - "visible-format" - Show formatted value (expands collections)
 //   - "sensitive" - Show "(sensitive)" placeholder
+//   - "url-redacted" - Show a connection string/URL with only its secrets redacted
 //   - "hidden" - Omit from DebugMap entirely
 //
 // Example struct:
@@ -37,28 +120,67 @@
 //	    Password string `debugmap:"sensitive"`
 //	    Data     []byte `debugmap:"hidden"`
 //	}
+//
+// Untagged fields fail generation unless a default policy is set with
+// -debugmap-default or a "//optgen:debugmap-default=hidden" directive in the
+// struct's doc comment. Fields with a "visible" default are still checked for
+// sensitive names and types.
+//
+// Fields whose names look sensitive but aren't can be acknowledged inline with
+// `debugmap:"visible,allow-sensitive-name"`.
+//
+// Fields whose type implements interface{ Sensitive() } or is listed in
+// -sensitive-types cannot be marked "visible" or "visible-format".
+//
+// The opt-in "flags" emitter generates RegisterXFlags(fs, prefix), which defines
+// a flag for each field with a `flag:"name,usage"` tag and returns a function
+// that turns the flags that were set into an option.
+//
+// The opt-in "env" emitter generates XOptionsFromEnv(prefix), which returns an
+// option for each field whose environment variable is set. Variables are named
+// prefix followed by the field's `env:"NAME"` tag or its name in UPPER_SNAKE_CASE,
+// and nested structs are read with the field's name and "_" appended to prefix.
+//
+// The opt-in "map" emitter generates XOptionsFromMap(m), which returns options
+// for the fields set in a decoded config document keyed by their json or yaml
+// tag names, and XOptionsFromJSON(r) and XOptionsFromYAML(r), which decode the
+// document first. Values of the wrong type and unknown keys are errors.
+//
+// All generation errors (missing or unknown tags, sensitive fields marked
+// visible, unsupported field types) are collected and reported together with
+// their source positions, and no output is written if there are any. The
+// same checks are available without generating as a go vet analyzer, see
+// github.com/ecordell/optgen/cmd/optgen-vet.
 package main
 
 import (
+	"bytes"
 	"errors"
-	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"unicode"
+	"unicode/utf8"
 
 	_ "github.com/creasty/defaults"
 	"github.com/dave/jennifer/jen"
-	"github.com/fatih/structtag"
-)
 
-type WriterProvider func() io.Writer
+	"github.com/ecordell/optgen/internal/debugmap"
+	"github.com/ecordell/optgen/internal/directive"
+	"github.com/ecordell/optgen/internal/fingerprint"
+)
 
 // TODO: struct tags to know what to generate
 // TODO: recursive generation, i.e. WithMetadata(WithName())
@@ -66,117 +188,381 @@ type WriterProvider func() io.Writer
 // TODO: configurable field prefix
 // TODO: exported / unexported generation
 
-var DefaultSensitiveNames = "secure"
-
 func main() {
-	fs := flag.NewFlagSet("optgen", flag.ContinueOnError)
-	outputPathFlag := fs.String(
-		"output",
-		"",
-		"Location where generated options will be written",
-	)
-	pkgNameFlag := fs.String(
-		"package",
-		"",
-		"Name of package to use in output file",
+	runCommand(os.Args[1:])
+}
+
+// runGenerate generates options for the structs named in args, or with check
+// only verifies that the existing outputs are up to date.
+func runGenerate(cmd *command, args []string, check bool) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	parallelFlag := fs.Int(
+		"parallel",
+		runtime.GOMAXPROCS(0),
+		"Maximum number of packages to generate in parallel",
 	)
-	sensitiveFieldNamesFlag := fs.String(
-		"sensitive-field-name-matches",
-		DefaultSensitiveNames,
-		"Substring matches of field names that should be considered sensitive",
+	allFlag := fs.Bool(
+		"all",
+		false,
+		"Generate options for every struct annotated with an //optgen:generate directive",
 	)
-	prefixFlag := fs.Bool(
-		"prefix",
+	checkFlag := &check
+	if !check {
+		checkFlag = fs.Bool(
+			"check",
+			false,
+			"Verify that -output is up to date instead of writing it, printing a diff and exiting non-zero if it is not",
+		)
+	}
+	jsonDiagnosticsFlag := fs.Bool(
+		"json-diagnostics",
 		false,
-		"Prefix generated function names with struct name (e.g., WithServerPort instead of WithPort)",
+		"Report generation errors as a JSON array of {file, line, column, message} objects",
 	)
+	cmd.parse(fs, args)
 
-	if err := fs.Parse(os.Args[1:]); err != nil {
-		log.Fatal(err.Error())
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if len(fs.Args()) < 2 {
-		// TODO: usage
-		log.Fatal("must specify a package directory and a struct to provide options for")
+	// "-" as the package reads a single Go file from stdin, whose options are
+	// written to stdout
+	fromStdin := fs.Arg(0) == stdioPath
+	if fromStdin {
+		if flagConfig.Package == nil {
+			log.Fatal("-package is required when reading a Go file from stdin")
+		}
+		stdout := stdioPath
+		flagConfig.Output = &stdout
+	}
+	toStdout := flagConfig.Output != nil && *flagConfig.Output == stdioPath
+	if toStdout && *checkFlag {
+		log.Fatal("-check can't be used when writing to stdout")
+	}
+	// Progress messages must not end up in the generated code
+	progress := io.Writer(os.Stdout)
+	if toStdout {
+		progress = os.Stderr
 	}
 
-	pkgName := fs.Arg(0)
-	structNames := fs.Args()[1:]
+	if len(fs.Args()) < 1 || (len(fs.Args()) < 2 && !*allFlag) {
+		cmd.usageError(fs, "must specify a package directory and a struct to provide options for, or -all")
+	}
+	if *parallelFlag < 1 {
+		log.Fatal("-parallel must be at least 1")
+	}
+
+	// The first argument is always a package; later arguments are struct
+	// names, or more packages if they aren't identifiers (e.g. ./internal/...)
+	pkgArgs := []string{fs.Arg(0)}
+	structNames := make([]string, 0)
+	for _, arg := range fs.Args()[1:] {
+		if token.IsIdentifier(arg) {
+			structNames = append(structNames, arg)
+		} else {
+			pkgArgs = append(pkgArgs, arg)
+		}
+	}
 	structFilter := make(map[string]struct{}, len(structNames))
 	for _, structName := range structNames {
 		structFilter[structName] = struct{}{}
 	}
 
-	var writer WriterProvider
-	if outputPathFlag != nil {
-		writer = func() io.Writer {
-			w, err := os.OpenFile(*outputPathFlag, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o600)
-			if err != nil {
-				log.Fatalf("couldn't open %s for writing", *outputPathFlag)
-			}
-			return w
+	var packages *loadedPackages
+	if fromStdin {
+		if len(pkgArgs) > 1 {
+			log.Fatal("a Go file read from stdin can't be combined with other packages")
+		}
+		// There is no package directory to type-check, so generate without
+		// type information
+		packages = &loadedPackages{Dirs: []string{stdioPath}, Fset: token.NewFileSet()}
+	} else {
+		packages, err = loadPackages(pkgArgs, false)
+		if err != nil {
+			log.Fatal(err)
 		}
 	}
+	if len(packages.Dirs) > 1 && flagConfig.Output != nil {
+		log.Fatal("-output can't be used with multiple packages, set outputs with //optgen:generate directives or a config file instead")
+	}
 
-	// Determine package name from output directory or flag
-	packageName := func() string {
-		if pkgNameFlag != nil && *pkgNameFlag != "" {
-			return *pkgNameFlag
+	layersByDir := make(map[string]ConfigLayers, len(packages.Dirs))
+	pkgSettingsByDir := make(map[string]Settings, len(packages.Dirs))
+	for _, dir := range packages.Dirs {
+		// Stdin uses the config file of the working directory
+		configDir := dir
+		if dir == stdioPath {
+			configDir = "."
 		}
-		// Parse a Go file in the output directory to get package name
-		outputDir := filepath.Dir(*outputPathFlag)
-		fset := token.NewFileSet()
-		pkgs, err := parser.ParseDir(fset, outputDir, nil, parser.PackageClauseOnly)
-		if err != nil || len(pkgs) == 0 {
-			return "main" // fallback
+		layers, err := loadConfigLayers(configDir, flagConfig)
+		if err != nil {
+			log.Fatal(err)
 		}
-		for name := range pkgs {
-			return name
+		// Report invalid package-wide settings before parsing anything
+		pkgSettings, err := newSettings(layers.Package(), nil)
+		if err != nil {
+			log.Fatal(err)
 		}
-		return "main"
-	}()
+		layersByDir[dir] = layers
+		pkgSettingsByDir[dir] = pkgSettings
+	}
 
-	sensitiveNameMatches := make([]string, 0)
-	if sensitiveFieldNamesFlag != nil {
-		sensitiveNameMatches = strings.Split(*sensitiveFieldNamesFlag, ",")
+	// Determine package name from settings or output directory, or the
+	// struct's package when writing to stdout
+	packageName := func(spec StructSpec) string {
+		if spec.Package != "" {
+			return spec.Package
+		}
+		if spec.Output == stdioPath {
+			return spec.File.Name.Name
+		}
+		return inferPackageName(filepath.Dir(spec.Output))
+	}
+
+	// generatedFile is the rendered code for one output file
+	type generatedFile struct {
+		path    string
+		content []byte
 	}
 
-	err := func() error {
-		fset := token.NewFileSet()
-		pkgs, err := parser.ParseDir(fset, pkgName, nil, parser.ParseComments)
+	fset := packages.Fset
+	diags := NewDiagnostics(fset)
+
+	// generatePackage renders the options for the requested structs in the
+	// package in dir. With an output pattern, it also returns the files that
+	// the pattern generated for structs that no longer exist.
+	generatePackage := func(dir string) ([]generatedFile, []string, error) {
+		var pkgs map[string]*ast.Package
+		var err error
+		if dir == stdioPath {
+			pkgs, err = parseStdin(fset, os.Stdin)
+		} else {
+			pkgs, err = packages.parsed(dir)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "parse: %v\n", err)
-			os.Exit(1)
+			return nil, nil, fmt.Errorf("parse: %w", err)
 		}
 
-		count := 0
+		generated := make([]generatedFile, 0)
+		stale := make([]string, 0)
 		for _, pkg := range pkgs {
-			for _, f := range pkg.Files {
-				structs := findStructDefsAST(f, structFilter)
-				if len(structs) == 0 {
-					continue
+			pkgTypes := packages.types(dir)
+
+			// Group the structs by output file, in source order
+			outputs := make(map[string][]StructSpec)
+			outputOrder := make([]string, 0)
+			for _, srcPath := range sortedFileNames(pkg) {
+				f := pkg.Files[srcPath]
+				for _, ts := range findStructDefsAST(f, structFilter, *allFlag) {
+					config := layersByDir[dir].Struct(ts.Name.Name)
+					settings, err := newSettings(config, pkgTypes)
+					if err != nil {
+						return nil, nil, fmt.Errorf("settings for %s: %w", ts.Name.Name, err)
+					}
+					settings.Diags = diags
+
+					output := *config.Output
+					if output == "" && settings.OutputPattern != nil {
+						output, err = expandOutputPattern(settings.OutputPattern, dir, ts.Name.Name, pkg.Name)
+						if err != nil {
+							return nil, nil, fmt.Errorf("output for %s: %w", ts.Name.Name, err)
+						}
+					}
+					spec := newStructSpec(f, ts, srcPath, settings, output)
+					if toStdout {
+						// Everything goes to stdout, even with output= arguments
+						spec.Output = stdioPath
+					}
+					spec.Package = *config.Package
+					if _, ok := outputs[spec.Output]; !ok {
+						outputOrder = append(outputOrder, spec.Output)
+					}
+					outputs[spec.Output] = append(outputs[spec.Output], spec)
+				}
+			}
+
+			// Nested structs are only read through the functions of emitters
+			// that generate them, from any output of the package
+			pkgEmitters := make(map[string][]string)
+			for _, structs := range outputs {
+				for _, spec := range structs {
+					pkgEmitters[spec.Spec.Name.Name] = spec.Settings.Emitters
+				}
+			}
+
+			for _, outpath := range outputOrder {
+				structs := outputs[outpath]
+				names := make([]string, 0, len(structs))
+				for _, spec := range structs {
+					names = append(names, spec.Spec.Name.Name)
 				}
-				fmt.Printf("Generating options for %s.%s...\n", packageName, strings.Join(structNames, ", "))
-				err = generateForFileAST(f, structs, packageName, f.Name.Name, *outputPathFlag, sensitiveNameMatches, *prefixFlag, writer)
+				outPkgName := packageName(structs[0])
+				fmt.Fprintf(progress, "Generating options for %s.%s...\n", outPkgName, strings.Join(names, ", "))
+				content, err := generateOutputAST(fset, structs, pkgEmitters, outPkgName, outpath, progress)
 				if err != nil {
-					return err
+					return nil, nil, err
+				}
+				generated = append(generated, generatedFile{path: outpath, content: content})
+			}
+
+			if pattern := pkgSettingsByDir[dir].OutputPattern; pattern != nil {
+				pkgStale, err := staleOutputs(pattern, dir, pkg.Name, declaredStructNames(pkg))
+				if err != nil {
+					return nil, nil, err
+				}
+				stale = append(stale, pkgStale...)
+			}
+		}
+		return generated, stale, nil
+	}
+
+	// Generate the packages in parallel, keeping everything in memory until
+	// all of them are known to be free of errors
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		generated []generatedFile
+		stale     []string
+		firstErr  error
+	)
+	workers := make(chan struct{}, *parallelFlag)
+	for _, dir := range packages.Dirs {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			files, staleFiles, err := generatePackage(dir)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", dir, err)
+			}
+			generated = append(generated, files...)
+			stale = append(stale, staleFiles...)
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		log.Fatal(firstErr)
+	}
+	if len(generated) == 0 {
+		log.Fatal("no structs found")
+	}
+	if diags.Len() > 0 {
+		if err := diags.Print(os.Stderr, *jsonDiagnosticsFlag); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	}
+
+	sort.Slice(generated, func(i, j int) bool {
+		return generated[i].path < generated[j].path
+	})
+	outOfDate := false
+	for _, file := range generated {
+		if *checkFlag {
+			// Compare against the existing file instead of writing it
+			existing, err := os.ReadFile(file.path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Fatal(err)
+			}
+			if diff := unifiedDiff(file.path, file.path+" (generated)", existing, file.content); diff != "" {
+				reason := "doesn't exist"
+				if err == nil {
+					reason = outOfDateReason(existing, file.content)
 				}
-				count++
+				fmt.Print(diff)
+				fmt.Fprintf(os.Stderr, "%s %s, rerun optgen to regenerate it\n", file.path, reason)
+				outOfDate = true
 			}
+			continue
 		}
-		if count == 0 {
-			return errors.New("no structs found")
+		if file.path == stdioPath {
+			if _, err := os.Stdout.Write(file.content); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+		if _, err := writeFileIfChanged(file.path, file.content); err != nil {
+			log.Fatalf("couldn't write %s: %v", file.path, err)
 		}
-		return nil
-	}()
-	if err != nil {
-		log.Fatal(err)
 	}
+
+	// Remove the outputs of deleted structs, unless another struct's output
+	// (e.g. set with an output= argument) now uses the same file
+	generatedPaths := make(map[string]struct{}, len(generated))
+	for _, file := range generated {
+		generatedPaths[filepath.Clean(file.path)] = struct{}{}
+	}
+	sort.Strings(stale)
+	for _, path := range stale {
+		if _, ok := generatedPaths[filepath.Clean(path)]; ok {
+			continue
+		}
+		if *checkFlag {
+			fmt.Fprintf(os.Stderr, "%s is stale, rerun optgen to remove it\n", path)
+			outOfDate = true
+			continue
+		}
+		fmt.Fprintf(progress, "Removing stale %s...\n", path)
+		if err := os.Remove(path); err != nil {
+			log.Fatalf("couldn't remove %s: %v", path, err)
+		}
+	}
+
+	if outOfDate {
+		os.Exit(1)
+	}
+}
+
+// declaredStructNames returns the names of all struct types declared in pkg.
+func declaredStructNames(pkg *ast.Package) []string {
+	names := make([]string, 0)
+	for _, f := range pkg.Files {
+		for _, ts := range allStructDefsAST(f) {
+			names = append(names, ts.Name.Name)
+		}
+	}
+	return names
+}
+
+// inferPackageName returns the name of the (non-test) package in dir, or
+// "main" if there is none.
+func inferPackageName(dir string) string {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.PackageClauseOnly)
+	if err != nil || len(pkgs) == 0 {
+		return "main" // fallback
+	}
+	for name := range pkgs {
+		if !strings.HasSuffix(name, "_test") {
+			return name
+		}
+	}
+	return "main"
+}
+
+// sortedFileNames returns the paths of the files in pkg in a stable order.
+func sortedFileNames(pkg *ast.Package) []string {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-// findStructDefsAST finds struct type definitions in an AST file that match the given names.
+// findStructDefsAST finds struct type definitions in an AST file that match the given names,
+// or that are annotated with an //optgen:generate directive if annotated is set.
 // It returns a slice of *ast.TypeSpec for each matching struct type.
-func findStructDefsAST(file *ast.File, names map[string]struct{}) []*ast.TypeSpec {
+func findStructDefsAST(file *ast.File, names map[string]struct{}, annotated bool) []*ast.TypeSpec {
 	found := make([]*ast.TypeSpec, 0)
 	ast.Inspect(file, func(node ast.Node) bool {
 		var ts *ast.TypeSpec
@@ -190,7 +576,7 @@ func findStructDefsAST(file *ast.File, names map[string]struct{}) []*ast.TypeSpe
 			return true
 		}
 
-		if _, ok := names[ts.Name.Name]; !ok {
+		if _, ok := names[ts.Name.Name]; !ok && !(annotated && directive.Has(file, ts, directive.Generate)) {
 			return false
 		}
 
@@ -205,6 +591,54 @@ func findStructDefsAST(file *ast.File, names map[string]struct{}) []*ast.TypeSpe
 	return found
 }
 
+// allStructDefsAST returns the struct types declared at the top level of file,
+// in source order.
+func allStructDefsAST(file *ast.File) []*ast.TypeSpec {
+	found := make([]*ast.TypeSpec, 0)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				if _, isStruct := ts.Type.(*ast.StructType); isStruct {
+					found = append(found, ts)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// Settings are the generation settings shared by all structs in a run
+type Settings struct {
+	Rules            *debugmap.Rules
+	UsePrefix        bool
+	DebugMapMaxDepth int
+
+	// OptionNameTemplate names option functions instead of UsePrefix, if set
+	OptionNameTemplate *template.Template
+
+	// OutputPattern names a separate output file per struct, if set
+	OutputPattern *template.Template
+
+	// Emitters are the kinds of code to generate, see EmitterOptions,
+	// EmitterDebugMap, EmitterFlags, EmitterEnv and EmitterMap
+	Emitters []string
+
+	// FlagPackage is the flag package used by EmitterFlags, see
+	// FlagPackageFlag and FlagPackagePflag
+	FlagPackage string
+
+	// DebugMapDefault is the policy for fields without a debugmap tag, which
+	// structs can override with an //optgen:debugmap-default directive
+	DebugMapDefault string
+
+	// Diags collects the generation errors of the run
+	Diags *Diagnostics
+}
+
 type Config struct {
 	ReceiverId     string
 	OptTypeName    string
@@ -213,6 +647,57 @@ type Config struct {
 	StructName     string
 	PkgPath        string
 	UsePrefix      bool
+
+	// OptionNameTemplate names option functions instead of UsePrefix, if set
+	OptionNameTemplate *template.Template
+
+	// DebugMapMaxDepth is the nesting depth past which DebugMap stops expanding
+	DebugMapMaxDepth int
+
+	// DebugMapDefault is the policy for fields without a debugmap tag
+	DebugMapDefault string
+
+	// Diags collects generation errors for the struct's fields
+	Diags *Diagnostics
+
+	// PkgEmitters are the emitters of the structs of the package generated in
+	// the same run, by name, and Pkg is the type-checked package if available
+	PkgEmitters map[string][]string
+	Pkg         *types.Package
+}
+
+// newConfig returns the generation config of a struct with the given settings.
+func newConfig(structName string, settings Settings) Config {
+	return Config{
+		ReceiverId:     strings.ToLower(string(structName[0])),
+		OptTypeName:    fmt.Sprintf("%sOption", structName),
+		TargetTypeName: toTitle(structName),
+		StructRef:      []jen.Code{jen.Id(structName)},
+		StructName:     structName,
+		PkgPath:        "", // Not needed for AST-based generation
+		UsePrefix:      settings.UsePrefix,
+
+		OptionNameTemplate: settings.OptionNameTemplate,
+
+		DebugMapMaxDepth: settings.DebugMapMaxDepth,
+		DebugMapDefault:  settings.DebugMapDefault,
+		Diags:            settings.Diags,
+	}
+}
+
+// nestedGenerated reports whether funcName, which emitter generates for a
+// nested struct of the package, exists after generating: the struct is
+// generated in the same run with the emitter, or it isn't generated in this
+// run and the package already declares funcName.
+func (c Config) nestedGenerated(nested, emitter, funcName string) bool {
+	if emitters, ok := c.PkgEmitters[nested]; ok {
+		return contains(emitters, emitter)
+	}
+	if c.Pkg == nil {
+		return false
+	}
+	_, ok := c.Pkg.Scope().Lookup(funcName).(*types.Func)
+	return ok
 }
 
 // prefix returns the struct name if UsePrefix is true, otherwise empty string
@@ -223,8 +708,62 @@ func (c Config) prefix() string {
 	return ""
 }
 
+// optionFuncName returns the name of the option function for a field, e.g.
+// WithPort, or WithServerPort with UsePrefix
+func (c Config) optionFuncName(verb, fieldName string) string {
+	if c.OptionNameTemplate == nil {
+		return verb + c.prefix() + toTitle(fieldName)
+	}
+	// The template was checked when the settings were loaded
+	var name strings.Builder
+	_ = c.OptionNameTemplate.Execute(&name, optionNameData{Verb: verb, Struct: c.StructName, Field: toTitle(fieldName)})
+	return name.String()
+}
+
+// fingerprint describes the settings that affect the generated code, for the
+// settings hash in the output's header.
+func (s Settings) fingerprint() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "prefix=%t\n", s.UsePrefix)
+	if s.OptionNameTemplate != nil {
+		fmt.Fprintf(&b, "option-name-template=%s\n", s.OptionNameTemplate.Root)
+	}
+	fmt.Fprintf(&b, "emitters=%s\n", strings.Join(s.Emitters, ","))
+	if s.emits(EmitterFlags) {
+		fmt.Fprintf(&b, "flag-package=%s\n", s.FlagPackage)
+	}
+	fmt.Fprintf(&b, "debugmap-default=%s\n", s.DebugMapDefault)
+	fmt.Fprintf(&b, "debugmap-max-depth=%d\n", s.DebugMapMaxDepth)
+	if s.Rules != nil {
+		fmt.Fprintf(&b, "sensitive-field-name-matches=%v\n", s.Rules.NameMatches)
+		fmt.Fprintf(&b, "sensitive-field-name-allow=%v\n", s.Rules.AllowedNames)
+		types := make([]string, 0, len(s.Rules.Types))
+		for t := range s.Rules.Types {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		fmt.Fprintf(&b, "sensitive-types=%s\n", strings.Join(types, ","))
+	}
+	return b.String()
+}
+
+// emits reports whether the settings enable the given emitter
+func (s Settings) emits(emitter string) bool {
+	return contains(s.Emitters, emitter)
+}
+
 const (
-	DebugMapFieldTag = "debugmap"
+	// redactPkgPath is the runtime package used by generated url-redacted fields
+	redactPkgPath = "github.com/ecordell/optgen/redact"
+
+	// debugMapWithStateFuncName is the unexported DebugMap variant that carries
+	// depth and cycle tracking state through nested optgen types
+	debugMapWithStateFuncName   = "debugMapWithState"
+	debugMapCyclePlaceholder    = "(cycle)"
+	debugMapMaxDepthPlaceholder = "(max depth)"
+
+	// DefaultDebugMapMaxDepth is the default nesting depth expanded by DebugMap
+	DefaultDebugMapMaxDepth = 10
 
 	// Type categories for debug code generation
 	typeCategoryPrimitive = "primitive"
@@ -270,89 +809,116 @@ func (r *ImportResolver) Resolve(pkgName string) string {
 	return pkgName
 }
 
-// parseStructTag parses a struct field tag and returns the value for the given key.
-// Returns an error if the tag is missing or cannot be parsed.
-func parseStructTag(field *ast.Field, tagKey string) (string, error) {
-	if field.Tag == nil {
-		return "", fmt.Errorf("missing tag")
-	}
-	// field.Tag.Value is like `debugmap:"visible"` (includes backticks)
-	tagStr := strings.Trim(field.Tag.Value, "`")
-	tags, err := structtag.Parse(tagStr)
-	if err != nil {
-		return "", err
-	}
-	tag, err := tags.Get(tagKey)
-	if err != nil {
-		return "", err
-	}
-	return tag.Value(), nil
-}
-
-// generateForFileAST generates functional options code for the given struct types.
-// It creates option types, constructor functions, and utility methods for each struct.
-func generateForFileAST(file *ast.File, typeSpecs []*ast.TypeSpec, pkgName, fileName, outpath string, sensitiveNameMatches []string, usePrefix bool, writer WriterProvider) error {
+// generateOutputAST generates functional options code for the given structs into a
+// single output file and returns its formatted content. It creates option types,
+// constructor functions, and utility methods for each struct. The header records
+// the hashes of the structs, their settings and the generated code.
+// pkgEmitters are the emitters of every struct of the package generated in
+// the same run, by name. Notes about the structs are written to progress.
+func generateOutputAST(fset *token.FileSet, structs []StructSpec, pkgEmitters map[string][]string, pkgName, outpath string, progress io.Writer) ([]byte, error) {
 	outdir, err := filepath.Abs(filepath.Dir(outpath))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Create import resolver for cross-package types
-	resolver := NewImportResolver(file)
-
 	buf := jen.NewFilePathName(outpath, pkgName)
-	buf.PackageComment("Code generated by github.com/ecordell/optgen. DO NOT EDIT.")
+	buf.PackageComment(strings.TrimPrefix(fingerprint.Header, "// "))
+
+	for _, spec := range structs {
+		file, ts, settings := spec.File, spec.Spec, spec.Settings
+
+		// Create import resolver for cross-package types
+		resolver := NewImportResolver(file)
 
-	for _, ts := range typeSpecs {
 		st, ok := ts.Type.(*ast.StructType)
 		if !ok {
-			return errors.New("type is not a struct")
+			return nil, errors.New("type is not a struct")
 		}
 
 		structName := ts.Name.Name
-		config := Config{
-			ReceiverId:     strings.ToLower(string(structName[0])),
-			OptTypeName:    fmt.Sprintf("%sOption", structName),
-			TargetTypeName: toTitle(structName),
-			StructRef:      []jen.Code{jen.Id(structName)},
-			StructName:     structName,
-			PkgPath:        "", // Not needed for AST-based generation
-			UsePrefix:      usePrefix,
+
+		// Struct-level directives override the run settings
+		debugMapDefault := settings.DebugMapDefault
+		for _, d := range directive.ForType(file, ts) {
+			if d.Name != directive.DebugMapDefault {
+				continue
+			}
+			if !debugmap.IsValidDefault(d.Value) {
+				settings.Diags.Addf(d.Comment.Pos(), "invalid %s%s value %q on type %s: must be one of %s", directive.Prefix, directive.DebugMapDefault, d.Value, structName, strings.Join(debugmap.Defaults, ", "))
+				continue
+			}
+			debugMapDefault = d.Value
 		}
 
-		// generate the Option type
-		writeOptionTypeAST(buf, config)
+		config := newConfig(structName, settings)
+		config.DebugMapDefault = debugMapDefault
+		config.PkgEmitters = pkgEmitters
+		config.Pkg = settings.Rules.Pkg
 
-		// generate NewXWithOptions
-		writeNewXWithOptionsAST(buf, config)
+		if settings.emits(EmitterOptions) {
+			// generate the Option type
+			writeOptionTypeAST(buf, config)
 
-		// generate NewXWithOptionsAndDefaults
-		writeNewXWithOptionsAndDefaultsAST(buf, config)
+			// generate NewXWithOptions
+			writeNewXWithOptionsAST(buf, config)
 
-		// generate ToOption
-		writeToOptionAST(buf, st, config)
+			// generate NewXWithOptionsAndDefaults
+			writeNewXWithOptionsAndDefaultsAST(buf, config)
 
-		// generate DebugMap
-		writeDebugMapAST(buf, st, config, sensitiveNameMatches)
+			// generate ToOption
+			writeToOptionAST(buf, st, config)
+		}
 
-		// generate WithOptions
-		writeXWithOptionsAST(buf, config)
-		writeWithOptionsAST(buf, config)
+		if settings.emits(EmitterDebugMap) {
+			// generate DebugMap
+			defaulted := writeDebugMapAST(buf, st, config, settings.Rules, resolver)
+			if len(defaulted) > 0 {
+				fmt.Fprintf(progress, "Fields without a debugmap tag in %s defaulted to %s: %s\n", structName, config.DebugMapDefault, strings.Join(defaulted, ", "))
+			}
+		}
 
-		// generate all With* functions
-		writeAllWithOptFuncsAST(buf, st, outdir, config, resolver)
-	}
+		if settings.emits(EmitterOptions) {
+			// generate WithOptions
+			writeXWithOptionsAST(buf, config)
+			writeWithOptionsAST(buf, config)
 
-	w := writer()
-	if w == nil {
-		optFile := strings.Replace(fileName, ".go", "_opts.go", 1)
-		w, err = os.OpenFile(optFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o600)
-		if err != nil {
-			return err
+			// generate all With* functions
+			writeAllWithOptFuncsAST(buf, st, outdir, config, resolver)
+		}
+
+		if settings.emits(EmitterFlags) {
+			// generate RegisterXFlags
+			writeRegisterFlagsAST(buf, st, config, settings.FlagPackage, resolver)
+		}
+
+		if settings.emits(EmitterEnv) {
+			// generate XOptionsFromEnv
+			writeOptionsFromEnvAST(buf, st, file, config, settings.Rules, resolver)
+		}
+
+		if settings.emits(EmitterMap) {
+			// generate XOptionsFromMap, XOptionsFromJSON and XOptionsFromYAML
+			writeOptionsFromMapAST(buf, st, file, config, settings.Rules, resolver)
 		}
 	}
 
-	return buf.Render(w)
+	var rendered bytes.Buffer
+	if err := buf.Render(&rendered); err != nil {
+		return nil, err
+	}
+	content, err := format.Source(rendered.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	sources := make([]fingerprint.Struct, 0, len(structs))
+	settings := make([]string, 0, len(structs))
+	for _, spec := range structs {
+		sources = append(sources, fingerprint.Struct{File: spec.File, Spec: spec.Spec})
+		settings = append(settings, spec.Spec.Name.Name+":\n"+spec.Settings.fingerprint())
+	}
+	sort.Strings(settings)
+	return fingerprint.Stamp(content, fingerprint.Source(fset, sources), fingerprint.Settings(strings.Join(settings, ""))), nil
 }
 
 func writeOptionTypeAST(buf *jen.File, c Config) {
@@ -399,11 +965,41 @@ func writeToOptionAST(buf *jen.File, st *ast.StructType, c Config) {
 	})
 }
 
-func writeDebugMapAST(buf *jen.File, st *ast.StructType, c Config, sensitiveNameMatches []string) {
+// writeDebugMapAST generates the DebugMap methods and returns the names of the
+// fields that fell back to the default debugmap policy
+func writeDebugMapAST(buf *jen.File, st *ast.StructType, c Config, rules *debugmap.Rules, resolver *ImportResolver) []string {
+	defaulted := make([]string, 0)
+
 	newFuncName := "DebugMap"
 
 	buf.Comment(fmt.Sprintf("%s returns a map form of %s for debugging", newFuncName, c.TargetTypeName))
 	buf.Func().Params(jen.Id(c.ReceiverId).Op("*").Id(c.StructName)).Id(newFuncName).Params().Id("map[string]any").BlockFunc(func(grp *jen.Group) {
+		grp.List(jen.Id("debugMap"), jen.Id("_")).Op(":=").Id(c.ReceiverId).Dot(debugMapWithStateFuncName).Call(
+			jen.Lit(0),
+			jen.Map(jen.Uintptr()).Struct().Values(),
+		).Assert(jen.Map(jen.String()).Any())
+		grp.Return(jen.Id("debugMap"))
+	})
+
+	// Generate debugMapWithState method
+	buf.Comment(fmt.Sprintf("%s returns a map form of %s for debugging, tracking the nesting", debugMapWithStateFuncName, c.TargetTypeName))
+	buf.Comment(fmt.Sprintf("depth and the structs being expanded so that cycles and nesting deeper than %d", c.DebugMapMaxDepth))
+	buf.Comment(fmt.Sprintf("levels are replaced with %q and %q placeholders", debugMapCyclePlaceholder, debugMapMaxDepthPlaceholder))
+	buf.Func().Params(jen.Id(c.ReceiverId).Op("*").Id(c.StructName)).Id(debugMapWithStateFuncName).Params(
+		jen.Id("depth").Int(),
+		jen.Id("seen").Map(jen.Uintptr()).Struct(),
+	).Any().BlockFunc(func(grp *jen.Group) {
+		grp.If(jen.Id("depth").Op(">").Lit(c.DebugMapMaxDepth)).Block(
+			jen.Return(jen.Lit(debugMapMaxDepthPlaceholder)),
+		)
+		grp.Id("ptr").Op(":=").Qual("reflect", "ValueOf").Call(jen.Id(c.ReceiverId)).Dot("Pointer").Call()
+		// A struct shares its address with its first field, so only the
+		// outermost of them registers (and unregisters) the address.
+		grp.If(jen.List(jen.Id("_"), jen.Id("ok")).Op(":=").Id("seen").Index(jen.Id("ptr")), jen.Op("!").Id("ok")).Block(
+			jen.Id("seen").Index(jen.Id("ptr")).Op("=").Struct().Values(),
+			jen.Defer().Delete(jen.Id("seen"), jen.Id("ptr")),
+		)
+
 		mapId := "debugMap"
 		grp.Id(mapId).Op(":=").Map(jen.String()).Any().Values()
 
@@ -419,7 +1015,9 @@ func writeDebugMapAST(buf *jen.File, st *ast.StructType, c Config, sensitiveName
 					continue
 				}
 
-				processDebugMapField(grp, field, name.Name, c, sensitiveNameMatches, mapId)
+				if processDebugMapField(grp, field, name.Name, c, rules, mapId, resolver) {
+					defaulted = append(defaulted, name.Name)
+				}
 			}
 		}
 
@@ -428,12 +1026,15 @@ func writeDebugMapAST(buf *jen.File, st *ast.StructType, c Config, sensitiveName
 
 	// Generate FlatDebugMap method
 	writeFlatDebugMapAST(buf, c)
+
+	return defaulted
 }
 
 // writeFlatDebugMapAST generates a FlatDebugMap method that flattens nested maps inline
 func writeFlatDebugMapAST(buf *jen.File, c Config) {
 	buf.Comment(fmt.Sprintf("FlatDebugMap returns a flattened map form of %s for debugging", c.TargetTypeName))
-	buf.Comment("Nested maps are flattened using dot notation (e.g., \"parent.child.field\")")
+	buf.Comment("Nested maps are flattened using dot notation (e.g., \"parent.child.field\"), and slices")
+	buf.Comment("containing nested maps are flattened by index (e.g., \"parent.0.field\")")
 	buf.Func().Params(jen.Id(c.ReceiverId).Op("*").Id(c.StructName)).Id("FlatDebugMap").Params().Id("map[string]any").BlockFunc(func(grp *jen.Group) {
 		// Define a recursive anonymous function to flatten maps
 		grp.Var().Id("flatten").Func().Params(
@@ -445,8 +1046,21 @@ func writeFlatDebugMapAST(buf *jen.File, c Config) {
 		).Map(jen.String()).Any().BlockFunc(func(fnGrp *jen.Group) {
 			fnGrp.Id("result").Op(":=").Make(jen.Map(jen.String()).Any(), jen.Len(jen.Id("m")))
 			fnGrp.For(jen.List(jen.Id("key"), jen.Id("value")).Op(":=").Range().Id("m")).BlockFunc(func(forGrp *jen.Group) {
+				// Index slices that contain nested maps so they flatten like maps
+				forGrp.If(jen.List(jen.Id("items"), jen.Id("ok")).Op(":=").Id("value").Assert(jen.Index().Any()), jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+					ifGrp.Id("indexed").Op(":=").Make(jen.Map(jen.String()).Any(), jen.Len(jen.Id("items")))
+					ifGrp.Id("hasMap").Op(":=").False()
+					ifGrp.For(jen.List(jen.Id("i"), jen.Id("item")).Op(":=").Range().Id("items")).Block(
+						jen.List(jen.Id("_"), jen.Id("isMap")).Op(":=").Id("item").Assert(jen.Map(jen.String()).Any()),
+						jen.Id("hasMap").Op("=").Id("hasMap").Op("||").Id("isMap"),
+						jen.Id("indexed").Index(jen.Qual("strconv", "Itoa").Call(jen.Id("i"))).Op("=").Id("item"),
+					)
+					ifGrp.If(jen.Id("hasMap")).Block(
+						jen.Id("value").Op("=").Id("indexed"),
+					)
+				})
 				forGrp.List(jen.Id("childMap"), jen.Id("ok")).Op(":=").Id("value").Assert(jen.Map(jen.String()).Any())
-				forGrp.If(jen.Id("ok")).BlockFunc(func(ifGrp *jen.Group) {
+				forGrp.If(jen.Id("ok").Op("&&").Len(jen.Id("childMap")).Op(">").Lit(0)).BlockFunc(func(ifGrp *jen.Group) {
 					ifGrp.For(jen.List(jen.Id("childKey"), jen.Id("childValue")).Op(":=").Range().Id("flatten").Call(jen.Id("childMap"))).Block(
 						jen.Id("result").Index(jen.Id("key").Op("+").Lit(".").Op("+").Id("childKey")).Op("=").Id("childValue"),
 					)
@@ -461,46 +1075,35 @@ func writeFlatDebugMapAST(buf *jen.File, c Config) {
 	})
 }
 
-// processDebugMapField processes a single field for debug map generation
-func processDebugMapField(grp *jen.Group, field *ast.Field, fieldName string, c Config, sensitiveNameMatches []string, mapId string) {
-	// Parse the debugmap tag
-	tagValue, err := parseStructTag(field, DebugMapFieldTag)
-	if err != nil {
-		fmt.Printf("missing debugmap tag on field %s in type %s\n", fieldName, c.TargetTypeName)
-		os.Exit(1)
+// processDebugMapField processes a single field for debug map generation.
+// It returns true if the field has no debugmap tag and the default policy was used.
+func processDebugMapField(grp *jen.Group, field *ast.Field, fieldName string, c Config, rules *debugmap.Rules, mapId string, resolver *ImportResolver) bool {
+	tag := debugmap.CheckField(field, fieldName, c.StructName, c.TargetTypeName, rules, c.DebugMapDefault)
+	for _, problem := range tag.Problems {
+		c.Diags.Addf(problem.Pos, "%s", problem.Message)
 	}
 
-	switch tagValue {
-	case "visible":
-		validateNotSensitive(fieldName, c.TargetTypeName, sensitiveNameMatches)
+	switch tag.Value {
+	case debugmap.Visible:
 		generateDebugCodeByCategory(grp, field.Type, c.ReceiverId, fieldName, mapId, false)
 
-	case "visible-format":
-		validateNotSensitive(fieldName, c.TargetTypeName, sensitiveNameMatches)
+	case debugmap.VisibleFormat:
 		generateDebugCodeByCategory(grp, field.Type, c.ReceiverId, fieldName, mapId, true)
 
-	case "hidden":
+	case debugmap.Hidden:
 		// Skip this field entirely
-		return
 
-	case "sensitive":
+	case debugmap.Sensitive:
 		category := getTypeCategory(field.Type)
 		generateDebugCodeForSensitive(grp, c.ReceiverId, fieldName, field.Type, category, mapId)
 
-	default:
-		fmt.Printf("unknown value '%s' for debugmap tag on field %s in type %s\n", tagValue, fieldName, c.TargetTypeName)
-		os.Exit(1)
-	}
-}
-
-// validateNotSensitive checks that a field name doesn't contain sensitive patterns
-func validateNotSensitive(fieldName, typeName string, sensitiveNameMatches []string) {
-	for _, sensitiveName := range sensitiveNameMatches {
-		if strings.Contains(strings.ToLower(fieldName), sensitiveName) {
-			fmt.Printf("field %s in type %s must be marked as 'sensitive'\n", fieldName, typeName)
-			os.Exit(1)
+	case debugmap.URLRedacted:
+		if !generateDebugCodeForURLRedacted(grp, c.ReceiverId, fieldName, field.Type, mapId, resolver) {
+			c.Diags.Addf(field.Type.Pos(), "url-redacted field %s in type %s must be a string, *string or sql.NullString", fieldName, c.TargetTypeName)
 		}
 	}
+
+	return tag.Defaulted
 }
 
 // generateDebugCodeByCategory generates debug code based on type category
@@ -519,25 +1122,82 @@ func generateDebugCodeByCategory(grp *jen.Group, fieldType ast.Expr, receiverId,
 		}
 	case typeCategoryMap:
 		if useFormat {
-			generateDebugCodeForMapFormat(grp, receiverId, fieldName, mapId)
+			generateDebugCodeForMapFormat(grp, receiverId, fieldName, fieldType, mapId)
 		} else {
 			generateDebugCodeForMapSize(grp, receiverId, fieldName, mapId)
 		}
 	default:
 		// Complex types: runtime interface check for DebugMap() — works for same-package,
 		// cross-package, and external types uniformly.
-		// Use pointer to cover both value-receiver and pointer-receiver DebugMap() methods.
-		grp.If(
-			jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(jen.Op("&").Id(receiverId).Dot(fieldName)).Assert(
-				jen.Interface(jen.Id("DebugMap").Params().Map(jen.String()).Any()),
-			),
+		generateDebugCodeForDelegation(grp, jen.Id(receiverId).Dot(fieldName), fieldType, func(value jen.Code) jen.Code {
+			return jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Add(value)
+		})
+	}
+}
+
+// generateDebugCodeForDelegation generates code that records the debug form of a
+// complex or pointer value, delegating to its debugMapWithState or DebugMap
+// method when it has one.
+//
+// Same-package optgen types are expanded through debugMapWithState so that depth
+// and cycle tracking carry across nested structs. Pointers are checked for nil
+// and, unless they point to a primitive, for cycles before being followed.
+// Values are checked through a pointer to cover both value-receiver and
+// pointer-receiver methods.
+func generateDebugCodeForDelegation(grp *jen.Group, target jen.Code, targetType ast.Expr, set func(value jen.Code) jen.Code) {
+	stateMapper := jen.Interface(jen.Id(debugMapWithStateFuncName).Params(jen.Int(), jen.Map(jen.Uintptr()).Struct()).Any())
+	debugMapper := jen.Interface(jen.Id("DebugMap").Params().Map(jen.String()).Any())
+
+	star, isPointer := targetType.(*ast.StarExpr)
+	if isPointer && getTypeCategory(star.X) == typeCategoryPrimitive {
+		// Pointer to primitive: nil check + runtime interface check for DebugMap() + dereference fallback
+		grp.If(jen.Add(target).Op("==").Nil()).Block(
+			set(jen.Lit("nil")),
+		).Else().If(
+			jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(target).Assert(debugMapper),
 			jen.Id("ok"),
 		).Block(
-			jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Id("dm").Dot("DebugMap").Call(),
+			set(jen.Id("dm").Dot("DebugMap").Call()),
 		).Else().Block(
-			jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Id(receiverId).Dot(fieldName),
+			set(jen.Op("*").Add(target)),
 		)
+		return
 	}
+
+	var stmt *jen.Statement
+	ref, fallback := jen.Op("&").Add(target), jen.Add(target)
+	if isPointer {
+		ref, fallback = jen.Add(target), jen.Op("*").Add(target)
+		stmt = grp.If(jen.Add(target).Op("==").Nil()).Block(
+			set(jen.Lit("nil")),
+		).Else().If(
+			jen.List(jen.Id("_"), jen.Id("ok")).Op(":=").Id("seen").Index(
+				jen.Qual("reflect", "ValueOf").Call(target).Dot("Pointer").Call(),
+			),
+			jen.Id("ok"),
+		).Block(
+			set(jen.Lit(debugMapCyclePlaceholder)),
+		).Else().If(
+			jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(ref).Assert(stateMapper),
+			jen.Id("ok"),
+		)
+	} else {
+		stmt = grp.If(
+			jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(ref).Assert(stateMapper),
+			jen.Id("ok"),
+		)
+	}
+
+	stmt.Block(
+		set(jen.Id("dm").Dot(debugMapWithStateFuncName).Call(jen.Id("depth").Op("+").Lit(1), jen.Id("seen"))),
+	).Else().If(
+		jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(ref).Assert(debugMapper),
+		jen.Id("ok"),
+	).Block(
+		set(jen.Id("dm").Dot("DebugMap").Call()),
+	).Else().Block(
+		set(fallback),
+	)
 }
 
 func writeXWithOptionsAST(buf *jen.File, c Config) {
@@ -557,12 +1217,28 @@ func writeWithOptionsAST(buf *jen.File, c Config) {
 }
 
 func writeAllWithOptFuncsAST(buf *jen.File, st *ast.StructType, outdir string, c Config, resolver *ImportResolver) {
+	checkOptionAliases(st, c)
 	for _, field := range st.Fields.List {
 		if field.Names == nil {
 			// Anonymous field, skip
 			continue
 		}
 
+		opts, err := parseOptgenTag(field)
+		if err != nil {
+			c.Diags.Addf(field.Tag.Pos(), "invalid optgen tag on field %s in type %s: %v", joinNames(field.Names), c.TargetTypeName, err)
+			continue
+		}
+
+		if unsupported := unsupportedTypeAST(field.Type); unsupported != nil {
+			for _, name := range field.Names {
+				if name.IsExported() {
+					c.Diags.Addf(unsupported.Pos(), "unsupported type %s for field %s in type %s", types.ExprString(unsupported), name.Name, c.TargetTypeName)
+				}
+			}
+			continue
+		}
+
 		for _, name := range field.Names {
 			if name.IsExported() {
 				fieldName := name.Name
@@ -578,16 +1254,20 @@ func writeAllWithOptFuncsAST(buf *jen.File, st *ast.StructType, outdir string, c
 				// Generate appropriate methods based on field type
 				if field.Type != nil {
 					if isSliceOrArrayAST(field.Type) {
-						writeSliceWithOptAST(buf, fieldName, field.Type, c, resolver)
-						writeSliceSetOptAST(buf, fieldName, fieldType, c)
+						writeSliceWithOptAST(buf, fieldName, field, c, resolver)
+						writeSliceSetOptAST(buf, fieldName, field, fieldType, c)
 					} else if isMapAST(field.Type) {
-						writeMapWithOptAST(buf, fieldName, field.Type, c, resolver)
-						writeMapSetOptAST(buf, fieldName, fieldType, c)
+						writeMapWithOptAST(buf, fieldName, field, c, resolver)
+						writeMapSetOptAST(buf, fieldName, field, fieldType, c)
 					} else {
-						writeStandardWithOptAST(buf, fieldName, fieldType, c)
+						writeStandardWithOptAST(buf, fieldName, field, fieldType, c)
 					}
 				} else {
-					writeStandardWithOptAST(buf, fieldName, fieldType, c)
+					writeStandardWithOptAST(buf, fieldName, field, fieldType, c)
+				}
+
+				if len(field.Names) == 1 {
+					writeAliasOptsAST(buf, fieldName, field, opts, fieldType, c, resolver)
 				}
 			}
 		}
@@ -595,13 +1275,13 @@ func writeAllWithOptFuncsAST(buf *jen.File, st *ast.StructType, outdir string, c
 }
 
 // writeSliceWithOptAST generates a With* method for slice fields using AST (appends)
-func writeSliceWithOptAST(buf *jen.File, fieldName string, fieldTypeAST ast.Expr, c Config, resolver *ImportResolver) {
-	fieldFuncName := fmt.Sprintf("With%s%s", c.prefix(), toTitle(fieldName))
-	buf.Comment(fmt.Sprintf("%s returns an option that can append %ss to %s.%s", fieldFuncName, toTitle(fieldName), c.StructName, fieldName))
+func writeSliceWithOptAST(buf *jen.File, fieldName string, field *ast.Field, c Config, resolver *ImportResolver) {
+	fieldFuncName := c.optionFuncName("With", fieldName)
+	writeOptDocAST(buf, fmt.Sprintf("%s returns an option that can append %ss to %s.%s", fieldFuncName, toTitle(fieldName), c.StructName, fieldName), fieldName, field, c)
 
 	// Extract element type from slice/array AST
 	var elemType jen.Code
-	if arrayType, ok := fieldTypeAST.(*ast.ArrayType); ok {
+	if arrayType, ok := field.Type.(*ast.ArrayType); ok {
 		elemType = astTypeToJenCode(arrayType.Elt, resolver)
 	} else {
 		elemType = jen.Interface()
@@ -619,18 +1299,18 @@ func writeSliceWithOptAST(buf *jen.File, fieldName string, fieldTypeAST ast.Expr
 }
 
 // writeSliceSetOptAST generates a Set* method for slice fields using AST (replaces)
-func writeSliceSetOptAST(buf *jen.File, fieldName string, fieldType jen.Code, c Config) {
-	writeSetterOptAST(buf, "Set", fieldName, fieldType, c)
+func writeSliceSetOptAST(buf *jen.File, fieldName string, field *ast.Field, fieldType jen.Code, c Config) {
+	writeSetterOptAST(buf, "Set", fieldName, field, fieldType, c)
 }
 
 // writeMapWithOptAST generates a With* method for map fields using AST (adds key-value)
-func writeMapWithOptAST(buf *jen.File, fieldName string, fieldTypeAST ast.Expr, c Config, resolver *ImportResolver) {
-	fieldFuncName := fmt.Sprintf("With%s%s", c.prefix(), toTitle(fieldName))
-	buf.Comment(fmt.Sprintf("%s returns an option that can append %ss to %s.%s", fieldFuncName, toTitle(fieldName), c.StructName, fieldName))
+func writeMapWithOptAST(buf *jen.File, fieldName string, field *ast.Field, c Config, resolver *ImportResolver) {
+	fieldFuncName := c.optionFuncName("With", fieldName)
+	writeOptDocAST(buf, fmt.Sprintf("%s returns an option that can append %ss to %s.%s", fieldFuncName, toTitle(fieldName), c.StructName, fieldName), fieldName, field, c)
 
 	// Extract key and value types from map AST
 	var keyType, valueType jen.Code
-	if mapType, ok := fieldTypeAST.(*ast.MapType); ok {
+	if mapType, ok := field.Type.(*ast.MapType); ok {
 		keyType = astTypeToJenCode(mapType.Key, resolver)
 		valueType = astTypeToJenCode(mapType.Value, resolver)
 	} else {
@@ -651,19 +1331,19 @@ func writeMapWithOptAST(buf *jen.File, fieldName string, fieldTypeAST ast.Expr,
 }
 
 // writeMapSetOptAST generates a Set* method for map fields using AST (replaces)
-func writeMapSetOptAST(buf *jen.File, fieldName string, fieldType jen.Code, c Config) {
-	writeSetterOptAST(buf, "Set", fieldName, fieldType, c)
+func writeMapSetOptAST(buf *jen.File, fieldName string, field *ast.Field, fieldType jen.Code, c Config) {
+	writeSetterOptAST(buf, "Set", fieldName, field, fieldType, c)
 }
 
 // writeStandardWithOptAST generates a With* method for standard fields using AST
-func writeStandardWithOptAST(buf *jen.File, fieldName string, fieldType jen.Code, c Config) {
-	writeSetterOptAST(buf, "With", fieldName, fieldType, c)
+func writeStandardWithOptAST(buf *jen.File, fieldName string, field *ast.Field, fieldType jen.Code, c Config) {
+	writeSetterOptAST(buf, "With", fieldName, field, fieldType, c)
 }
 
 // writeSetterOptAST generates a setter option function (used by slice, map, and standard setters)
-func writeSetterOptAST(buf *jen.File, funcPrefix, fieldName string, fieldType jen.Code, c Config) {
-	fieldFuncName := fmt.Sprintf("%s%s%s", funcPrefix, c.prefix(), toTitle(fieldName))
-	buf.Comment(fmt.Sprintf("%s returns an option that can set %s on a %s", fieldFuncName, toTitle(fieldName), c.StructName))
+func writeSetterOptAST(buf *jen.File, funcPrefix, fieldName string, field *ast.Field, fieldType jen.Code, c Config) {
+	fieldFuncName := c.optionFuncName(funcPrefix, fieldName)
+	writeOptDocAST(buf, fmt.Sprintf("%s returns an option that can set %s on a %s", fieldFuncName, toTitle(fieldName), c.StructName), fieldName, field, c)
 
 	buf.Func().Id(fieldFuncName).Params(
 		jen.Id(unexport(fieldName)).Add(fieldType),
@@ -676,6 +1356,83 @@ func writeSetterOptAST(buf *jen.File, funcPrefix, fieldName string, fieldType je
 	})
 }
 
+// writeOptDocAST writes the doc comment of an option function: the summary,
+// the field's doc comment or line comment, a note on its default and how
+// DebugMap shows it, and last any Deprecated: paragraph of the field or the
+// deprecated message of its optgen tag, so that gopls and staticcheck warn on
+// uses of the option too.
+func writeOptDocAST(buf *jen.File, summary, fieldName string, field *ast.Field, c Config) {
+	buf.Comment(summary)
+
+	doc := field.Doc.Text()
+	if strings.TrimSpace(doc) == "" {
+		doc = field.Comment.Text()
+	}
+	paragraphs := make([]string, 0)
+	deprecated := make([]string, 0)
+	for _, paragraph := range strings.Split(strings.TrimSpace(doc), "\n\n") {
+		switch {
+		case strings.TrimSpace(paragraph) == "":
+		case strings.HasPrefix(paragraph, "Deprecated: "):
+			deprecated = append(deprecated, paragraph)
+		default:
+			// A single line without punctuation would be formatted as a
+			// heading, as would be likely for line comments
+			if last, _ := utf8.DecodeLastRuneInString(paragraph); unicode.IsLetter(last) || unicode.IsDigit(last) {
+				paragraph += "."
+			}
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+
+	notes := make([]string, 0, 2)
+	if value := fieldDefault(field); value != "" {
+		notes = append(notes, fmt.Sprintf("%s defaults to %s with New%sWithOptionsAndDefaults.", fieldName, value, c.TargetTypeName))
+	}
+	if note := debugMapNote(field, fieldName, c.DebugMapDefault); note != "" {
+		notes = append(notes, note)
+	}
+	if len(notes) > 0 {
+		paragraphs = append(paragraphs, strings.Join(notes, " "))
+	}
+
+	// A deprecated field without aliases has its own options deprecated
+	if opts, err := parseOptgenTag(field); err == nil && opts.deprecated != "" && len(opts.aliases) == 0 && len(deprecated) == 0 {
+		deprecated = append(deprecated, "Deprecated: "+opts.deprecated)
+	}
+
+	for _, paragraph := range append(paragraphs, deprecated...) {
+		buf.Comment("//")
+		for _, line := range strings.Split(paragraph, "\n") {
+			// Written verbatim, since jen renders lines starting with // or /*
+			// as is
+			buf.Comment(strings.TrimRight("// "+line, " "))
+		}
+	}
+}
+
+// debugMapNote describes how DebugMap shows a field whose value it doesn't
+// show as is, or returns "".
+func debugMapNote(field *ast.Field, fieldName, defaultPolicy string) string {
+	value, err := debugmap.LookupTag(field, debugmap.Tag)
+	if errors.Is(err, debugmap.ErrMissingTag) {
+		value, err = defaultPolicy, nil
+	}
+	if err != nil {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, ",")
+	switch value {
+	case debugmap.Sensitive:
+		return fmt.Sprintf("%s is sensitive: DebugMap shows it as %q.", fieldName, "(sensitive)")
+	case debugmap.URLRedacted:
+		return fmt.Sprintf("DebugMap shows %s with its secrets replaced by %q.", fieldName, "xxxxx")
+	case debugmap.Hidden:
+		return fmt.Sprintf("DebugMap leaves %s out.", fieldName)
+	}
+	return ""
+}
+
 // isSliceOrArrayAST checks if an AST type is a slice or array
 func isSliceOrArrayAST(t ast.Expr) bool {
 	_, ok := t.(*ast.ArrayType)
@@ -743,6 +1500,58 @@ func astTypeToJenCode(expr ast.Expr, resolver *ImportResolver) jen.Code {
 	}
 }
 
+// unsupportedTypeAST returns the part of a field type that astTypeToJenCode
+// cannot reproduce, or nil if the whole type is supported. Fixed-size arrays,
+// function types, inline structs and non-empty inline interfaces would
+// otherwise silently become interface{} or slices in the generated options.
+func unsupportedTypeAST(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return nil
+	case *ast.StarExpr:
+		return unsupportedTypeAST(t.X)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); ok {
+			return nil
+		}
+		return t
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return t
+		}
+		return unsupportedTypeAST(t.Elt)
+	case *ast.MapType:
+		if unsupported := unsupportedTypeAST(t.Key); unsupported != nil {
+			return unsupported
+		}
+		return unsupportedTypeAST(t.Value)
+	case *ast.InterfaceType:
+		if t.Methods != nil && len(t.Methods.List) > 0 {
+			return t
+		}
+		return nil
+	case *ast.ChanType:
+		return unsupportedTypeAST(t.Value)
+	case *ast.IndexExpr:
+		if unsupported := unsupportedTypeAST(t.X); unsupported != nil {
+			return unsupported
+		}
+		return unsupportedTypeAST(t.Index)
+	case *ast.IndexListExpr:
+		if unsupported := unsupportedTypeAST(t.X); unsupported != nil {
+			return unsupported
+		}
+		for _, index := range t.Indices {
+			if unsupported := unsupportedTypeAST(index); unsupported != nil {
+				return unsupported
+			}
+		}
+		return nil
+	default:
+		return expr
+	}
+}
+
 // getTypeCategory returns the category of a type for debug generation
 func getTypeCategory(expr ast.Expr) string {
 	switch t := expr.(type) {
@@ -804,21 +1613,9 @@ func generateDebugCodeForPrimitive(grp *jen.Group, receiverId, fieldName string,
 
 // generateDebugCodeForPointer handles pointer types
 func generateDebugCodeForPointer(grp *jen.Group, receiverId, fieldName string, fieldType ast.Expr, mapId string) {
-	fieldAccess := jen.Id(receiverId).Dot(fieldName)
-
-	// nil check + runtime interface check for DebugMap() + dereference fallback.
-	grp.If(jen.Add(fieldAccess).Op("==").Nil()).Block(
-		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Lit("nil"),
-	).Else().If(
-		jen.List(jen.Id("dm"), jen.Id("ok")).Op(":=").Id("any").Call(fieldAccess).Assert(
-			jen.Interface(jen.Id("DebugMap").Params().Map(jen.String()).Any()),
-		),
-		jen.Id("ok"),
-	).Block(
-		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Id("dm").Dot("DebugMap").Call(),
-	).Else().Block(
-		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Op("*").Add(fieldAccess),
-	)
+	generateDebugCodeForDelegation(grp, jen.Id(receiverId).Dot(fieldName), fieldType, func(value jen.Code) jen.Code {
+		return jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Add(value)
+	})
 }
 
 // generateDebugCodeForSliceSize generates code for slice with size display (visible tag)
@@ -837,22 +1634,96 @@ func generateDebugCodeForSliceFormat(grp *jen.Group, receiverId, fieldName strin
 	).Else().Block(
 		jen.Id(debugVarName).Op(":=").Make(jen.Index().Any(), jen.Lit(0), jen.Len(fieldAccess)),
 		jen.For(jen.List(jen.Id("_"), jen.Id("v")).Op(":=").Range().Add(fieldAccess)).BlockFunc(func(forGrp *jen.Group) {
-			if elemType != nil && isStringType(elemType) {
-				// String slice: check for empty strings
-				forGrp.If(jen.Id("v").Op("==").Lit("")).Block(
-					jen.Id(debugVarName).Op("=").Append(jen.Id(debugVarName), jen.Lit("(empty)")),
-				).Else().Block(
-					jen.Id(debugVarName).Op("=").Append(jen.Id(debugVarName), jen.Id("v")),
-				)
-			} else {
-				// Other types: direct append
-				forGrp.Id(debugVarName).Op("=").Append(jen.Id(debugVarName), jen.Id("v"))
-			}
+			generateDebugCodeForElement(forGrp, elemType, 0, func(value jen.Code) jen.Code {
+				return jen.Id(debugVarName).Op("=").Append(jen.Id(debugVarName), value)
+			})
 		}),
 		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Id(debugVarName),
 	)
 }
 
+// generateDebugCodeForElement generates code that records the debug form of a
+// collection element held in "v", or "v2", "v3"... for the elements of nested
+// collections at depth 1, 2... Elements implementing DebugMap() are expanded
+// through it so that nested sensitive fields stay redacted, including in
+// nested slices and maps, which are expanded element by element.
+func generateDebugCodeForElement(grp *jen.Group, elemType ast.Expr, depth int, set func(value jen.Code) jen.Code) {
+	v := debugElementVar("v", depth)
+	switch getTypeCategory(elemType) {
+	case typeCategoryPrimitive:
+		if isStringType(elemType) {
+			// String: check for empty strings
+			grp.If(jen.Id(v).Op("==").Lit("")).Block(
+				set(jen.Lit("(empty)")),
+			).Else().Block(
+				set(jen.Id(v)),
+			)
+		} else {
+			// Other primitives: direct value
+			grp.Add(set(jen.Id(v)))
+		}
+	case typeCategorySlice, "array":
+		// Nested slices: expand each element like the outer slice
+		nestedV := debugElementVar("v", depth+1)
+		debugVarName := debugElementVar("debugV", depth+1)
+		expand := []jen.Code{
+			jen.Id(debugVarName).Op(":=").Make(jen.Index().Any(), jen.Lit(0), jen.Len(jen.Id(v))),
+			jen.For(jen.List(jen.Id("_"), jen.Id(nestedV)).Op(":=").Range().Id(v)).BlockFunc(func(forGrp *jen.Group) {
+				generateDebugCodeForElement(forGrp, getSliceElementType(elemType), depth+1, func(value jen.Code) jen.Code {
+					return jen.Id(debugVarName).Op("=").Append(jen.Id(debugVarName), value)
+				})
+			}),
+			set(jen.Id(debugVarName)),
+		}
+		if getTypeCategory(elemType) == "array" {
+			// Arrays can't be nil
+			grp.Add(expand...)
+			return
+		}
+		grp.If(jen.Id(v).Op("==").Nil()).Block(
+			set(jen.Lit("nil")),
+		).Else().Block(expand...)
+	case typeCategoryMap:
+		// Nested maps: expand each value like the outer map
+		mapType := elemType.(*ast.MapType)
+		nestedK, nestedV := debugElementVar("k", depth+1), debugElementVar("v", depth+1)
+		debugVarName := debugElementVar("debugV", depth+1)
+		grp.If(jen.Id(v).Op("==").Nil()).Block(
+			set(jen.Lit("nil")),
+		).Else().Block(
+			jen.Id(debugVarName).Op(":=").Make(jen.Map(jen.String()).Any(), jen.Len(jen.Id(v))),
+			jen.For(jen.List(jen.Id(nestedK), jen.Id(nestedV)).Op(":=").Range().Id(v)).BlockFunc(func(forGrp *jen.Group) {
+				generateDebugCodeForElement(forGrp, mapType.Value, depth+1, func(value jen.Code) jen.Code {
+					return jen.Id(debugVarName).Index(debugMapKey(mapType.Key, jen.Id(nestedK))).Op("=").Add(value)
+				})
+			}),
+			set(jen.Id(debugVarName)),
+		)
+	default:
+		// Pointers and complex types: delegate to the element's own DebugMap
+		generateDebugCodeForDelegation(grp, jen.Id(v), elemType, set)
+	}
+}
+
+// debugElementVar returns the name of a variable for the elements of a
+// collection nested depth levels deep: name itself at the top level, then
+// name2, name3...
+func debugElementVar(name string, depth int) string {
+	if depth == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s%d", name, depth+1)
+}
+
+// debugMapKey returns the key of a map entry in the debug form of the map:
+// string keys are used as-is, other keys are formatted.
+func debugMapKey(keyType ast.Expr, k jen.Code) jen.Code {
+	if keyType != nil && isStringType(keyType) {
+		return k
+	}
+	return jen.Qual("fmt", "Sprint").Call(k)
+}
+
 // generateDebugCodeForMapSize generates code for map with size display (visible tag)
 func generateDebugCodeForMapSize(grp *jen.Group, receiverId, fieldName, mapId string) {
 	generateDebugCodeForCollectionSize(grp, receiverId, fieldName, mapId, "map")
@@ -872,17 +1743,30 @@ func generateDebugCodeForCollectionSize(grp *jen.Group, receiverId, fieldName, m
 	)
 }
 
-// generateDebugCodeForMapFormat generates code for map with expanded values (visible-format tag)
-func generateDebugCodeForMapFormat(grp *jen.Group, receiverId, fieldName, mapId string) {
+// generateDebugCodeForMapFormat generates code for map with expanded values (visible-format tag).
+// The map is rendered as a map[string]any so that FlatDebugMap can flatten it per key.
+func generateDebugCodeForMapFormat(grp *jen.Group, receiverId, fieldName string, fieldType ast.Expr, mapId string) {
 	fieldAccess := jen.Id(receiverId).Dot(fieldName)
+	debugVarName := "debug" + fieldName
+
+	var keyType, valueType ast.Expr
+	if mapType, ok := fieldType.(*ast.MapType); ok {
+		keyType = mapType.Key
+		valueType = mapType.Value
+	}
+
+	key := debugMapKey(keyType, jen.Id("k"))
 
 	grp.If(jen.Add(fieldAccess).Op("==").Nil()).Block(
 		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Lit("nil"),
 	).Else().Block(
-		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Qual("fmt", "Sprintf").Call(
-			jen.Lit("%v"),
-			fieldAccess,
-		),
+		jen.Id(debugVarName).Op(":=").Make(jen.Map(jen.String()).Any(), jen.Len(fieldAccess)),
+		jen.For(jen.List(jen.Id("k"), jen.Id("v")).Op(":=").Range().Add(fieldAccess)).BlockFunc(func(forGrp *jen.Group) {
+			generateDebugCodeForElement(forGrp, valueType, 0, func(value jen.Code) jen.Code {
+				return jen.Id(debugVarName).Index(key).Op("=").Add(value)
+			})
+		}),
+		jen.Id(mapId).Index(jen.Lit(fieldName)).Op("=").Id(debugVarName),
 	)
 }
 
@@ -910,6 +1794,61 @@ func generateDebugCodeForSensitive(grp *jen.Group, receiverId, fieldName string,
 	}
 }
 
+// generateDebugCodeForURLRedacted generates code for url-redacted fields, which
+// are shown with only the secret parts of the connection string replaced.
+// It returns false if the field type is not a supported string type.
+func generateDebugCodeForURLRedacted(grp *jen.Group, receiverId, fieldName string, fieldType ast.Expr, mapId string, resolver *ImportResolver) bool {
+	fieldAccess := jen.Id(receiverId).Dot(fieldName)
+	mapEntry := jen.Id(mapId).Index(jen.Lit(fieldName))
+
+	var value *jen.Statement
+	var stmt *jen.Statement
+	switch {
+	case isStringType(fieldType):
+		value = jen.Add(fieldAccess)
+		stmt = grp.If(jen.Add(value).Op("==").Lit(""))
+	case isPointerToStringType(fieldType):
+		// Pointer: check nil, then empty
+		value = jen.Op("*").Add(fieldAccess)
+		stmt = grp.If(jen.Add(fieldAccess).Op("==").Nil()).Block(
+			jen.Add(mapEntry).Op("=").Lit("nil"),
+		).Else().If(jen.Add(value).Op("==").Lit(""))
+	case isSQLNullStringType(fieldType, resolver):
+		// sql.NullString: check Valid, then empty
+		value = jen.Add(fieldAccess).Dot("String")
+		stmt = grp.If(jen.Op("!").Add(fieldAccess).Dot("Valid")).Block(
+			jen.Add(mapEntry).Op("=").Lit("nil"),
+		).Else().If(jen.Add(value).Op("==").Lit(""))
+	default:
+		return false
+	}
+
+	stmt.Block(
+		jen.Add(mapEntry).Op("=").Lit("(empty)"),
+	).Else().Block(
+		jen.Add(mapEntry).Op("=").Qual(redactPkgPath, "ConnectionString").Call(value),
+	)
+	return true
+}
+
+// isPointerToStringType checks if a type is a *string
+func isPointerToStringType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return isStringType(star.X)
+	}
+	return false
+}
+
+// isSQLNullStringType checks if a type is database/sql.NullString
+func isSQLNullStringType(expr ast.Expr, resolver *ImportResolver) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "NullString" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && resolver.Resolve(pkg.Name) == "database/sql"
+}
+
 func applyOptions(receiverId string) func(grp *jen.Group) {
 	return func(grp *jen.Group) {
 		grp.For(jen.Id("_").Op(",").Id("opt").Op(":=").Op("range").Id("opts")).Block(
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packageAliases returns the alias options generated for the structs of a
+// package, mapped to the options they forward to.
+func packageAliases(structs map[string]packageStruct) map[string]string {
+	renames := make(map[string]string)
+	for structName, s := range structs {
+		c := newConfig(structName, s.settings)
+		for _, field := range s.spec.Type.(*ast.StructType).Fields.List {
+			opts, err := parseOptgenTag(field)
+			if err != nil || len(field.Names) != 1 {
+				continue
+			}
+			for from, to := range optionAliases(field.Names[0].Name, field, opts, c) {
+				renames[from] = to
+			}
+		}
+	}
+	return renames
+}
+
+// migrateFile renames the references in file to the options in renames,
+// which are the package-level functions of the package importPath that info
+// resolves them to, whether referenced unqualified, through an import or a
+// dot import. It returns the number of renamed references.
+func migrateFile(file *ast.File, info *types.Info, renames map[string]string, importPath string) int {
+	renamed := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		// Test variants of the package have their own objects, so the option
+		// is matched by its package path and name
+		fn, ok := info.Uses[ident].(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != importPath || fn.Type().(*types.Signature).Recv() != nil {
+			return true
+		}
+		if to, ok := renames[fn.Name()]; ok {
+			ident.Name = to
+			renamed++
+		}
+		return true
+	})
+	return renamed
+}
+
+// runMigrate rewrites references to the deprecated alias options of a
+// package's structs to the options they forward to, in the package itself and
+// the packages matching the patterns (default "./...").
+func runMigrate(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	dryRunFlag := fs.Bool(
+		"dry-run",
+		false,
+		"Print a unified diff of the changes instead of writing them",
+	)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 1 {
+		cmd.usageError(fs, "must specify the package directory that declares the options")
+	}
+	dir := fs.Arg(0)
+	patterns := fs.Args()[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	// With -dry-run, stdout is reserved for the diff
+	progress := io.Writer(os.Stdout)
+	if *dryRunFlag {
+		progress = os.Stderr
+	}
+
+	structs, _, err := loadPackageStructs(dir, flagConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	renames := packageAliases(structs)
+	if len(renames) == 0 {
+		fmt.Fprintf(progress, "No options in %s have aliases\n", dir)
+		return
+	}
+	loaded, err := loadPackages(append([]string{dir}, patterns...), true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importPath := loaded.importPath(dir)
+	if importPath == "" {
+		log.Fatalf("couldn't load the package in %s", dir)
+	}
+
+	// Test packages come first, as they hold the package's files too
+	dirs := make(map[string]bool, len(loaded.Dirs))
+	for _, pkgDir := range loaded.Dirs {
+		absPkgDir, err := filepath.Abs(pkgDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dirs[absPkgDir] = true
+	}
+	pkgs := make([]*packages.Package, 0, len(loaded.Packages))
+	for _, pkg := range loaded.Packages {
+		if dirs[pkg.Dir] {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		return isTestPackage(pkgs[i]) && !isTestPackage(pkgs[j])
+	})
+
+	migrated := make(map[string]bool)
+	total := 0
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			srcPath := loaded.Fset.File(file.Pos()).Name()
+			if migrated[srcPath] || ast.IsGenerated(file) || pkg.TypesInfo == nil {
+				continue
+			}
+			migrated[srcPath] = true
+			renamed := migrateFile(file, pkg.TypesInfo, renames, importPath)
+			if renamed == 0 {
+				continue
+			}
+			total += renamed
+
+			var out bytes.Buffer
+			if err := format.Node(&out, loaded.Fset, file); err != nil {
+				log.Fatalf("couldn't format %s: %v", srcPath, err)
+			}
+			srcPath = relativeToWorkingDir(srcPath)
+			if *dryRunFlag {
+				existing, err := os.ReadFile(srcPath)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Print(unifiedDiff(srcPath, srcPath, existing, out.Bytes()))
+				continue
+			}
+			if _, err := writeFileIfChanged(srcPath, out.Bytes()); err != nil {
+				log.Fatalf("couldn't write %s: %v", srcPath, err)
+			}
+			fmt.Fprintf(progress, "Migrated %d references in %s\n", renamed, srcPath)
+		}
+	}
+	if total == 0 {
+		fmt.Fprintln(progress, "No references to aliased options found")
+	}
+}
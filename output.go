@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ecordell/optgen/internal/fingerprint"
+)
+
+// stdioPath as -output writes the generated code to stdout, and as the package
+// argument reads a single Go file from stdin.
+const stdioPath = "-"
+
+// defaultOutputMode is the mode of newly created output files
+const defaultOutputMode os.FileMode = 0o644
+
+// writeFileIfChanged writes content to path unless the file already holds
+// exactly that content, so that unchanged regenerations keep their mtime and
+// don't invalidate build caches. The file is written to a temporary file in
+// the same directory and renamed into place, so a failed write never leaves
+// a partial file behind. Existing files keep their mode.
+// It returns whether the file was written.
+func writeFileIfChanged(path string, content []byte) (bool, error) {
+	mode := defaultOutputMode
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(existing, content) {
+			return false, nil
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	// Clean up the temporary file on any failure; after a successful rename
+	// this is a no-op
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// outputPatternFuncs are the functions available to -output-pattern templates
+var outputPatternFuncs = template.FuncMap{
+	"snake": toSnake,
+	"lower": strings.ToLower,
+}
+
+// outputPatternData is the data passed to -output-pattern templates
+type outputPatternData struct {
+	Struct  string
+	Package string
+}
+
+// parseOutputPattern parses an -output-pattern template such as
+// "{{.Struct | snake}}_options.go".
+func parseOutputPattern(pattern string) (*template.Template, error) {
+	tmpl, err := template.New("output-pattern").Funcs(outputPatternFuncs).Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output-pattern: %w", err)
+	}
+	if _, err := expandOutputPattern(tmpl, "", "Config", "config"); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// expandOutputPattern returns the output path of a struct in the package in
+// dir, which output patterns are relative to.
+func expandOutputPattern(tmpl *template.Template, dir, structName, pkgName string) (string, error) {
+	var name strings.Builder
+	if err := tmpl.Execute(&name, outputPatternData{Struct: structName, Package: pkgName}); err != nil {
+		return "", fmt.Errorf("invalid output-pattern: %w", err)
+	}
+	if name.Len() == 0 || filepath.IsAbs(name.String()) {
+		return "", fmt.Errorf("invalid output-pattern: %q is not a relative file name", name.String())
+	}
+	return filepath.Join(dir, name.String()), nil
+}
+
+// staleOutputs returns the optgen-generated files in dir that match the output
+// pattern but don't belong to any of the package's structs, e.g. because the
+// struct was removed or renamed.
+func staleOutputs(tmpl *template.Template, dir, pkgName string, structNames []string) ([]string, error) {
+	// Every struct's output matches the pattern with the struct name as a
+	// wildcard (as long as the template's functions keep "*" intact)
+	glob, err := expandOutputPattern(tmpl, dir, "*", pkgName)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]struct{}, len(structNames))
+	for _, structName := range structNames {
+		path, err := expandOutputPattern(tmpl, dir, structName, pkgName)
+		if err != nil {
+			return nil, err
+		}
+		current[filepath.Clean(path)] = struct{}{}
+	}
+
+	stale := make([]string, 0)
+	for _, path := range candidates {
+		if _, ok := current[filepath.Clean(path)]; ok {
+			continue
+		}
+		generated, err := isGeneratedFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if generated {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
+
+// isGeneratedFile reports whether the file at path was generated by optgen.
+// Only files with its header are ever removed as stale.
+func isGeneratedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(fingerprint.Header))
+	if _, err := io.ReadFull(f, header); err != nil {
+		// Too short to have the header
+		return false, nil
+	}
+	return string(header) == fingerprint.Header, nil
+}
+
+// outOfDateReason explains why an existing output differs from its regenerated
+// content, using the hashes recorded in their headers: the output was edited by
+// hand, or its structs or settings changed since it was generated.
+func outOfDateReason(existing, generated []byte) string {
+	recorded, ok := fingerprint.Parse(existing)
+	if !ok {
+		// Generated before optgen recorded hashes, or not by optgen at all
+		return "is out of date"
+	}
+	current, _ := fingerprint.Parse(generated)
+
+	reasons := make([]string, 0)
+	if fingerprint.Tampered(existing, recorded) {
+		reasons = append(reasons, "was edited by hand")
+	}
+	if recorded.Source != current.Source {
+		reasons = append(reasons, "is stale because its structs changed")
+	}
+	if recorded.Settings != current.Settings {
+		reasons = append(reasons, "is stale because its settings changed")
+	}
+	if len(reasons) == 0 {
+		// Most likely generated by a different version of optgen
+		return "is out of date"
+	}
+	return strings.Join(reasons, " and ")
+}
+
+// toSnake converts a Go identifier to snake_case, keeping initialisms
+// together: "HTTPServerConfig" becomes "http_server_config".
+func toSnake(s string) string {
+	runes := []rune(s)
+	var out strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				out.WriteByte('_')
+			}
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+	return out.String()
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadedPackages are the package directories to generate options for, along
+// with the packages that go/packages loaded for them.
+type loadedPackages struct {
+	// Dirs are the package directories, plain directory arguments first in
+	// the order given, then the packages matched by patterns
+	Dirs []string
+
+	// Fset holds the positions of the loaded packages' syntax
+	Fset *token.FileSet
+
+	// Packages are all loaded packages, including the test packages of the
+	// directories if they were requested
+	Packages []*packages.Package
+
+	// byDir maps absolute package directories to their loaded packages,
+	// without test packages
+	byDir map[string]*packages.Package
+}
+
+// isPackagePattern reports whether a package argument is a go-style pattern
+// such as ./... or ./internal/..., rather than a single directory.
+func isPackagePattern(arg string) bool {
+	return strings.Contains(arg, "...")
+}
+
+// loadMode is what loadPackages loads for each package: enough to type-check
+// it and to map its syntax to types. Dependencies are type-checked from
+// source too, like the analyzer does, since compiler export data can be newer
+// than the vendored go/packages can read.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedForTest
+
+// loadPackages resolves package directories and patterns and type-checks the
+// packages with a single packages.Load. Type errors are tolerated since
+// generated files are often stale while generating. Directories that can't
+// be loaded (e.g. outside of a module) are still generated for, just without
+// type information. With tests, the test packages of the directories are
+// loaded too.
+func loadPackages(args []string, tests bool) (*loadedPackages, error) {
+	loaded := &loadedPackages{
+		Fset:  token.NewFileSet(),
+		byDir: make(map[string]*packages.Package),
+	}
+
+	hasPatterns := false
+	loadArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if isPackagePattern(arg) {
+			hasPatterns = true
+			loadArgs = append(loadArgs, arg)
+			continue
+		}
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		loaded.Dirs = append(loaded.Dirs, arg)
+		loadArgs = append(loadArgs, abs)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:      loadMode,
+		Fset:      loaded.Fset,
+		ParseFile: parseLoadedFile,
+		Tests:     tests,
+	}, loadArgs...)
+	if err != nil && hasPatterns {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	loaded.Packages = pkgs
+	matched := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Dir == "" || isTestPackage(pkg) {
+			continue
+		}
+		if _, ok := loaded.byDir[pkg.Dir]; !ok {
+			matched = append(matched, pkg.Dir)
+		}
+		loaded.byDir[pkg.Dir] = pkg
+	}
+
+	if hasPatterns {
+		// Add the packages matched by patterns that weren't named directly
+		named := make(map[string]struct{}, len(loaded.Dirs))
+		for _, dir := range loaded.Dirs {
+			abs, _ := filepath.Abs(dir)
+			named[abs] = struct{}{}
+		}
+		sort.Strings(matched)
+		for _, dir := range matched {
+			if _, ok := named[dir]; ok {
+				continue
+			}
+			named[dir] = struct{}{}
+			loaded.Dirs = append(loaded.Dirs, relativeToWorkingDir(dir))
+		}
+		if len(loaded.Dirs) == 0 {
+			return nil, errors.New("no packages matched " + strings.Join(args, " "))
+		}
+	}
+
+	return loaded, nil
+}
+
+// dependencyRoots are the directories of the standard library, the module
+// cache and vendored modules, whose files are only type-checked as
+// dependencies
+var dependencyRoots = func() []string {
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		modCache = filepath.Join(build.Default.GOPATH, "pkg", "mod")
+	}
+	return []string{
+		filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator),
+		modCache + string(filepath.Separator),
+		string(filepath.Separator) + "vendor" + string(filepath.Separator),
+	}
+}()
+
+// parseLoadedFile parses a file for packages.Load. The function bodies of
+// dependencies outside the module are dropped, since type-checking the
+// packages doesn't need them, and the packages' own files are named relative
+// to the working directory, so that diagnostics and outputs show short paths.
+func parseLoadedFile(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	for _, root := range dependencyRoots {
+		if !strings.Contains(filename, root) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.AllErrors)
+		if f == nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				fn.Body = nil
+			}
+		}
+		return f, err
+	}
+	return parser.ParseFile(fset, relativeToWorkingDir(filename), src, parser.AllErrors|parser.ParseComments)
+}
+
+// isTestPackage reports whether pkg is a package variant that go/packages
+// loads for tests: the package with its _test.go files, the external test
+// package, or the generated test main package.
+func isTestPackage(pkg *packages.Package) bool {
+	return pkg.ForTest != "" || strings.HasSuffix(pkg.ID, ".test")
+}
+
+// pkg returns the loaded package in dir, or nil if it couldn't be loaded.
+func (p *loadedPackages) pkg(dir string) *packages.Package {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	return p.byDir[abs]
+}
+
+// importPath returns the import path of the package in dir, or "" if unknown.
+func (p *loadedPackages) importPath(dir string) string {
+	if pkg := p.pkg(dir); pkg != nil {
+		return pkg.PkgPath
+	}
+	return ""
+}
+
+// types returns the type-checked package in dir, or nil if it couldn't be
+// loaded, in which case fields only get name-based checks.
+func (p *loadedPackages) types(dir string) *types.Package {
+	if pkg := p.pkg(dir); pkg != nil {
+		return pkg.Types
+	}
+	return nil
+}
+
+// parsed returns the files of the package in dir by package name, in the
+// form parser.ParseDir returns them, from the syntax of the load. Directories
+// that couldn't be loaded (e.g. outside of a module) are parsed on their own,
+// leaving out tests.
+func (p *loadedPackages) parsed(dir string) (map[string]*ast.Package, error) {
+	if pkg := p.pkg(dir); pkg != nil && len(pkg.Syntax) > 0 {
+		files := make(map[string]*ast.File, len(pkg.Syntax))
+		for _, f := range pkg.Syntax {
+			files[p.Fset.File(f.Pos()).Name()] = f
+		}
+		return map[string]*ast.Package{pkg.Name: {Name: pkg.Name, Files: files}}, nil
+	}
+	return parser.ParseDir(p.Fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+}
+
+// stdinFileName is the file name of a Go file read from stdin in diagnostics
+const stdinFileName = "<stdin>"
+
+// parseStdin parses a single Go file from r, in the same form as
+// parser.ParseDir returns packages.
+func parseStdin(fset *token.FileSet, r io.Reader) (map[string]*ast.Package, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseFile(fset, stdinFileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*ast.Package{
+		f.Name.Name: {Name: f.Name.Name, Files: map[string]*ast.File{stdinFileName: f}},
+	}, nil
+}
+
+// relativeToWorkingDir returns dir relative to the working directory if it is
+// below it, so that diagnostics show short paths.
+func relativeToWorkingDir(dir string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return dir
+	}
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return dir
+	}
+	return rel
+}
+
+// packageStruct is a struct of a package with its settings, as loaded by
+// loadPackageStructs
+type packageStruct struct {
+	file     *ast.File
+	spec     *ast.TypeSpec
+	settings Settings
+}
+
+// loadPackageStructs returns the structs of the package in dir by name, with
+// their settings, which share the returned diagnostics.
+func loadPackageStructs(dir string, flagConfig GenerateConfig) (map[string]packageStruct, *Diagnostics, error) {
+	loaded, err := loadPackages([]string{dir}, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers, err := loadConfigLayers(dir, flagConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkgs, err := loaded.parsed(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, nil, fmt.Errorf("%s: expected one package, found %d", dir, len(pkgs))
+	}
+
+	diags := NewDiagnostics(loaded.Fset)
+	structs := make(map[string]packageStruct)
+	for _, pkg := range pkgs {
+		pkgTypes := loaded.types(dir)
+		for _, srcPath := range sortedFileNames(pkg) {
+			file := pkg.Files[srcPath]
+			for _, ts := range allStructDefsAST(file) {
+				settings, err := newSettings(layers.Struct(ts.Name.Name), pkgTypes)
+				if err != nil {
+					return nil, nil, fmt.Errorf("settings for %s: %w", ts.Name.Name, err)
+				}
+				settings.Diags = diags
+				structs[ts.Name.Name] = packageStruct{file: file, spec: ts, settings: settings}
+			}
+		}
+	}
+	return structs, diags, nil
+}
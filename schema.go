@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+	"github.com/ecordell/optgen/internal/directive"
+)
+
+const (
+	// jsonSchemaDialect is the JSON Schema version of the documents written by
+	// "optgen schema"
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+	// DefaultFieldTag holds a field's default value, as used by
+	// github.com/creasty/defaults
+	DefaultFieldTag = "default"
+
+	// ValidateFieldTag holds a field's validation rules, as used by
+	// github.com/go-playground/validator
+	ValidateFieldTag = "validate"
+
+	// durationPattern matches the strings that time.ParseDuration accepts
+	durationPattern = `^(0|[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+)$`
+)
+
+// validationFormats are the validate rules that map to a JSON Schema format
+var validationFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"hostname": "hostname",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"uuid":     "uuid",
+}
+
+// jsonSchema is a JSON Schema, with its keywords in the order they are written
+type jsonSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+
+	Enum    []any           `json:"enum,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+
+	Minimum          json.Number `json:"minimum,omitempty"`
+	Maximum          json.Number `json:"maximum,omitempty"`
+	ExclusiveMinimum json.Number `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum json.Number `json:"exclusiveMaximum,omitempty"`
+	MinLength        json.Number `json:"minLength,omitempty"`
+	MaxLength        json.Number `json:"maxLength,omitempty"`
+	MinItems         json.Number `json:"minItems,omitempty"`
+	MaxItems         json.Number `json:"maxItems,omitempty"`
+
+	WriteOnly bool `json:"writeOnly,omitempty"`
+
+	Items                *jsonSchema       `json:"items,omitempty"`
+	Properties           *schemaProperties `json:"properties,omitempty"`
+	AdditionalProperties any               `json:"additionalProperties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+
+	Defs map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// schemaProperties are the properties of an object schema, in field order
+type schemaProperties struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+func (p *schemaProperties) add(name string, schema *jsonSchema) {
+	if p.schemas == nil {
+		p.schemas = make(map[string]*jsonSchema)
+	}
+	if _, ok := p.schemas[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.schemas[name] = schema
+}
+
+// MarshalJSON writes the properties in field order rather than sorted.
+func (p *schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range p.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// schemaBuilder writes the schema of a struct and the structs it nests
+type schemaBuilder struct {
+	// structs are the structs of the package by name
+	structs map[string]packageStruct
+
+	// keyTag is the struct tag whose names are preferred for property names,
+	// "json" or "yaml"
+	keyTag string
+
+	// defs are the schemas of the nested structs that were referenced
+	defs map[string]*jsonSchema
+
+	diags *Diagnostics
+}
+
+// structSchema returns the schema of the object form of a struct. Nested
+// structs are added to b.defs and referenced.
+func (b *schemaBuilder) structSchema(s packageStruct) *jsonSchema {
+	st := s.spec.Type.(*ast.StructType)
+	schema := &jsonSchema{
+		Type:                 "object",
+		Properties:           &schemaProperties{},
+		AdditionalProperties: false,
+	}
+	if doc := typeDoc(s.file, s.spec); doc != "" {
+		schema.Description = doc
+	}
+
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			keys, err := documentKeys(field, name.Name)
+			if err != nil {
+				b.diags.Addf(field.Tag.Pos(), "invalid struct tag on field %s in type %s: %v", name.Name, s.spec.Name.Name, err)
+				continue
+			}
+			if len(keys) == 0 {
+				continue
+			}
+			key := keys[0]
+			if preferred, ok := tagName(field, b.keyTag); ok {
+				key = preferred
+			}
+
+			fieldSchema := b.fieldSchema(field.Type, s.settings.Rules.FieldType(s.spec.Name.Name, name.Name), s)
+			if doc := fieldDoc(field); doc != "" {
+				fieldSchema.Description = doc
+			}
+			if b.sensitive(field, name.Name, s) {
+				fieldSchema.WriteOnly = true
+			}
+			if value, err := debugmap.LookupTag(field, DefaultFieldTag); err == nil && fieldSchema.Ref == "" {
+				fieldSchema.Default = schemaDefault(value, fieldSchema.Type)
+			}
+			if value, err := debugmap.LookupTag(field, ValidateFieldTag); err == nil {
+				if applyValidation(fieldSchema, value) {
+					schema.Required = append(schema.Required, key)
+				}
+			}
+			schema.Properties.add(key, fieldSchema)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of a field type. t is the field type if the
+// package could be type-checked.
+func (b *schemaBuilder) fieldSchema(expr ast.Expr, t types.Type, s packageStruct) *jsonSchema {
+	resolver := NewImportResolver(s.file)
+	if star, ok := expr.(*ast.StarExpr); ok {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		return b.fieldSchema(star.X, t, s)
+	}
+
+	if nested := nestedStructAST(expr, t, s.file); nested != "" {
+		if ns, ok := b.structs[nested]; ok {
+			if _, ok := b.defs[nested]; !ok {
+				// Reserve the name first so that recursive structs terminate
+				b.defs[nested] = nil
+				b.defs[nested] = b.structSchema(ns)
+			}
+			return &jsonSchema{Ref: "#/$defs/" + nested}
+		}
+	}
+
+	if scalar, ok := scalarOf(expr, t, resolver); ok {
+		return scalarSchema(scalar)
+	}
+
+	switch fieldType := expr.(type) {
+	case *ast.ArrayType:
+		if fieldType.Len == nil {
+			var elemType types.Type
+			if slice, ok := underlying(t).(*types.Slice); ok {
+				elemType = slice.Elem()
+			}
+			return &jsonSchema{Type: "array", Items: b.fieldSchema(fieldType.Elt, elemType, s)}
+		}
+	case *ast.MapType:
+		var keyType, valueType types.Type
+		if m, ok := underlying(t).(*types.Map); ok {
+			keyType, valueType = m.Key(), m.Elem()
+		}
+		if key, ok := scalarOf(fieldType.Key, keyType, resolver); ok && key.kind == "string" {
+			return &jsonSchema{Type: "object", AdditionalProperties: b.fieldSchema(fieldType.Value, valueType, s)}
+		}
+	}
+
+	// Types without a document form accept any value
+	return &jsonSchema{}
+}
+
+// sensitive reports whether DebugMap treats the named field as sensitive.
+func (b *schemaBuilder) sensitive(field *ast.Field, fieldName string, s packageStruct) bool {
+	return debugMapValue(field, fieldName, s) == debugmap.Sensitive
+}
+
+// debugMapValue returns how DebugMap shows the named field of a struct, as
+// generating checks it: by its debugmap tag or the struct's policy for
+// untagged fields, or "sensitive" if the settings' sensitive names or types
+// match a field that isn't marked so, which generating reports. It returns ""
+// for other invalid or missing tags.
+func debugMapValue(field *ast.Field, fieldName string, s packageStruct) string {
+	structName := s.spec.Name.Name
+	tag := debugmap.CheckField(field, fieldName, structName, structName, s.settings.Rules, s.settings.DebugMapDefault)
+	for _, problem := range tag.Problems {
+		if problem.Fix == debugmap.Sensitive {
+			return debugmap.Sensitive
+		}
+	}
+	return tag.Value
+}
+
+// scalarSchema returns the schema of a scalar type, with the range of sized
+// integers.
+func scalarSchema(scalar scalarType) *jsonSchema {
+	switch scalar.kind {
+	case "string":
+		return &jsonSchema{Type: "string"}
+	case "bool":
+		return &jsonSchema{Type: "boolean"}
+	case "int":
+		schema := &jsonSchema{Type: "integer"}
+		if scalar.bits > 0 && scalar.bits < 64 {
+			schema.Minimum = json.Number(strconv.FormatInt(-1<<(scalar.bits-1), 10))
+			schema.Maximum = json.Number(strconv.FormatInt(1<<(scalar.bits-1)-1, 10))
+		}
+		return schema
+	case "uint":
+		schema := &jsonSchema{Type: "integer", Minimum: "0"}
+		if scalar.bits > 0 && scalar.bits < 64 {
+			schema.Maximum = json.Number(strconv.FormatUint(1<<scalar.bits-1, 10))
+		}
+		return schema
+	case "float":
+		return &jsonSchema{Type: "number"}
+	case "duration":
+		return &jsonSchema{Type: "string", Pattern: durationPattern}
+	}
+	return &jsonSchema{}
+}
+
+// schemaDefault returns the JSON form of a default tag for a schema type, or
+// nil if it can't be converted. Lists and objects are written as JSON in the
+// tag, as github.com/creasty/defaults expects.
+func schemaDefault(value, schemaType string) json.RawMessage {
+	var parsed any
+	switch schemaType {
+	case "string":
+		parsed = value
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil
+		}
+		parsed = b
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil
+		}
+		parsed = json.Number(value)
+	case "array", "object":
+		if !json.Valid([]byte(value)) {
+			return nil
+		}
+		return json.RawMessage(value)
+	default:
+		return nil
+	}
+	raw, err := json.Marshal(parsed)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// applyValidation adds the constraints of a validate tag to a schema, and
+// returns whether the tag marks the field required. Rules without a JSON
+// Schema equivalent are ignored.
+func applyValidation(schema *jsonSchema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if format, ok := validationFormats[name]; ok {
+			schema.Format = format
+			continue
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, option := range strings.Fields(param) {
+				if schema.Type == "integer" || schema.Type == "number" {
+					schema.Enum = append(schema.Enum, json.Number(option))
+				} else {
+					schema.Enum = append(schema.Enum, option)
+				}
+			}
+		case "min", "gte", "max", "lte", "gt", "lt", "len":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				continue
+			}
+			applyBound(schema, name, json.Number(param))
+		}
+	}
+	return required
+}
+
+// applyBound adds a min, max or len style validation to a schema, which limits
+// the length of strings, the number of items of lists and the value of numbers.
+func applyBound(schema *jsonSchema, rule string, bound json.Number) {
+	lower := rule == "min" || rule == "gte" || rule == "len"
+	upper := rule == "max" || rule == "lte" || rule == "len"
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = bound
+		}
+		if upper {
+			schema.MaxLength = bound
+		}
+	case "array":
+		if lower {
+			schema.MinItems = bound
+		}
+		if upper {
+			schema.MaxItems = bound
+		}
+	case "integer", "number":
+		switch {
+		case rule == "gt":
+			schema.ExclusiveMinimum = bound
+		case rule == "lt":
+			schema.ExclusiveMaximum = bound
+		default:
+			if lower {
+				schema.Minimum = bound
+			}
+			if upper {
+				schema.Maximum = bound
+			}
+		}
+	}
+}
+
+// tagName returns the name in a field's struct tag, if it has one.
+func tagName(field *ast.Field, key string) (string, bool) {
+	tag, err := debugmap.LookupTag(field, key)
+	if err != nil {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, name != "" && name != "-"
+}
+
+// typeDoc returns the doc comment of a type. CommentGroup.Text leaves out
+// directives such as //optgen:generate.
+func typeDoc(file *ast.File, ts *ast.TypeSpec) string {
+	return strings.TrimSpace(directive.TypeSpecDoc(file, ts).Text())
+}
+
+// fieldDoc returns the doc comment of a field, or its line comment.
+func fieldDoc(field *ast.Field) string {
+	if doc := strings.TrimSpace(field.Doc.Text()); doc != "" {
+		return doc
+	}
+	return strings.TrimSpace(field.Comment.Text())
+}
+
+// runSchema writes a JSON Schema document for each of the named structs.
+func runSchema(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	outputDirFlag := fs.String(
+		"output-dir",
+		"",
+		"Write each schema to <struct_name>.schema.json in this directory instead of stdout",
+	)
+	keyTagFlag := fs.String(
+		"key-tag",
+		"json",
+		"Struct tag whose names are used for properties: json or yaml",
+	)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 2 {
+		cmd.usageError(fs, "must specify a package directory and the structs to describe")
+	}
+	if fs.NArg() > 2 && *outputDirFlag == "" {
+		cmd.usageError(fs, "-output-dir is required for more than one struct")
+	}
+	if !contains(documentTags, *keyTagFlag) {
+		cmd.usageError(fs, "invalid -key-tag %q: must be one of %s", *keyTagFlag, strings.Join(documentTags, ", "))
+	}
+
+	structs, diags, err := loadPackageStructs(fs.Arg(0), flagConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	documents := make(map[string][]byte)
+	for _, structName := range fs.Args()[1:] {
+		s, ok := structs[structName]
+		if !ok {
+			log.Fatalf("struct %s not found in %s", structName, fs.Arg(0))
+		}
+		b := &schemaBuilder{structs: structs, keyTag: *keyTagFlag, defs: make(map[string]*jsonSchema), diags: diags}
+		schema := b.structSchema(s)
+		schema.Schema = jsonSchemaDialect
+		schema.Title = structName
+		if len(b.defs) > 0 {
+			schema.Defs = b.defs
+		}
+
+		document, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			log.Fatalf("couldn't encode the schema of %s: %v", structName, err)
+		}
+		documents[structName] = append(document, '\n')
+	}
+	if diags.Len() > 0 {
+		if err := diags.Print(os.Stderr, false); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(1)
+	}
+
+	for _, structName := range fs.Args()[1:] {
+		if *outputDirFlag == "" {
+			_, _ = os.Stdout.Write(documents[structName])
+			continue
+		}
+		path := filepath.Join(*outputDirFlag, toSnake(structName)+".schema.json")
+		if _, err := writeFileIfChanged(path, documents[structName]); err != nil {
+			log.Fatalf("couldn't write %s: %v", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	}
+}
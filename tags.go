@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ecordell/optgen/internal/debugmap"
+)
+
+// debugMapTagStatus describes the debugmap tags of a struct's exported fields
+type debugMapTagStatus struct {
+	Tagged int
+	Total  int
+
+	// Missing are the fields without a debugmap tag
+	Missing []string
+
+	// Invalid are the fields whose struct tag can't be parsed
+	Invalid []string
+}
+
+// debugMapTags returns the state of the debugmap tags of the fields that
+// DebugMap requires a tag for.
+func debugMapTags(st *ast.StructType) debugMapTagStatus {
+	status := debugMapTagStatus{}
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			status.Total++
+			_, err := debugmap.LookupTag(field, debugmap.Tag)
+			switch {
+			case err == nil:
+				status.Tagged++
+			case errors.Is(err, debugmap.ErrMissingTag):
+				status.Missing = append(status.Missing, name.Name)
+			default:
+				status.Invalid = append(status.Invalid, name.Name)
+			}
+		}
+	}
+	return status
+}
+
+// addDebugMapTags adds the suggested debugmap tag to every untagged exported
+// field of ts, keeping the fields' other tags. It returns the names of the
+// fields it tagged; fields with unparseable struct tags are left alone.
+func addDebugMapTags(ts *ast.TypeSpec, rules *debugmap.Rules) []string {
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	tagged := make([]string, 0)
+	for _, field := range st.Fields.List {
+		exported := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			if name.IsExported() {
+				exported = append(exported, name.Name)
+			}
+		}
+		if len(exported) == 0 {
+			continue
+		}
+		if _, err := debugmap.LookupTag(field, debugmap.Tag); !errors.Is(err, debugmap.ErrMissingTag) {
+			continue
+		}
+		tag, err := debugmap.SetTag(field, debugmap.Tag, debugmap.SuggestTag(field, ts.Name.Name, rules))
+		if err != nil {
+			continue
+		}
+		if field.Tag == nil {
+			field.Tag = &ast.BasicLit{ValuePos: field.Type.End(), Kind: token.STRING}
+		}
+		field.Tag.Value = tag
+		tagged = append(tagged, exported...)
+	}
+	return tagged
+}
+
+// hasOptgenGenerateLine reports whether file has a //go:generate line that
+// runs optgen.
+func hasOptgenGenerateLine(file *ast.File) bool {
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, "//go:generate ") && strings.Contains(comment.Text, "optgen") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// generateLine returns the //go:generate line that runs optgen for the
+// structs of a package with the explicitly set flags of fs.
+func generateLine(fs *flag.FlagSet, structNames []string) string {
+	args := []string{"//go:generate", "go", "run", "github.com/ecordell/optgen"}
+	fs.Visit(func(f *flag.Flag) {
+		arg := "-" + f.Name + "=" + f.Value.String()
+		// go generate splits arguments on spaces unless they are quoted
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	})
+	args = append(args, ".")
+	args = append(args, structNames...)
+	return strings.Join(args, " ")
+}
+
+// insertAfterPackageClause returns the formatted Go source src with line
+// inserted after its package clause.
+func insertAfterPackageClause(src []byte, line string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, err
+	}
+	offset := fset.Position(file.Name.End()).Offset
+	if newline := bytes.IndexByte(src[offset:], '\n'); newline >= 0 {
+		offset += newline + 1
+	} else {
+		offset = len(src)
+	}
+
+	var out bytes.Buffer
+	out.Write(src[:offset])
+	out.WriteString("\n" + line + "\n")
+	out.Write(src[offset:])
+	return format.Source(out.Bytes())
+}
+
+// taggedPackage is a package whose structs had debugmap tags added, before
+// the changed files are written
+type taggedPackage struct {
+	fset *token.FileSet
+	pkg  *ast.Package
+
+	// structNames are the matched structs, in source order
+	structNames []string
+
+	// structFiles are the files declaring the matched structs, in order
+	structFiles []string
+
+	// tagged maps files to whether tags were added to them
+	tagged map[string]bool
+}
+
+// tagPackageStructs adds debugmap tags to the untagged exported fields of the
+// structs in the package in dir that are named in structFilter or, if
+// annotated is set, have an //optgen:generate directive. Only the parsed
+// files are changed; see taggedPackage.format. report is called for each
+// struct that was tagged.
+func tagPackageStructs(dir string, flagConfig GenerateConfig, structFilter map[string]struct{}, annotated bool, report func(pkgName, structName string, fields []string)) ([]taggedPackage, error) {
+	packages, err := loadPackages([]string{dir}, false)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := loadConfigLayers(dir, flagConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := packages.Fset
+	pkgs, err := packages.parsed(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkgNames := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		pkgNames = append(pkgNames, name)
+	}
+	sort.Strings(pkgNames)
+
+	tagged := make([]taggedPackage, 0)
+	for _, pkgName := range pkgNames {
+		pkg := pkgs[pkgName]
+		pkgTypes := packages.types(dir)
+
+		tp := taggedPackage{fset: fset, pkg: pkg, tagged: make(map[string]bool)}
+		for _, srcPath := range sortedFileNames(pkg) {
+			structs := findStructDefsAST(pkg.Files[srcPath], structFilter, annotated)
+			if len(structs) > 0 {
+				tp.structFiles = append(tp.structFiles, srcPath)
+			}
+			for _, ts := range structs {
+				settings, err := newSettings(layers.Struct(ts.Name.Name), pkgTypes)
+				if err != nil {
+					return nil, fmt.Errorf("settings for %s: %w", ts.Name.Name, err)
+				}
+				if fields := addDebugMapTags(ts, settings.Rules); len(fields) > 0 {
+					report(pkgName, ts.Name.Name, fields)
+					tp.tagged[srcPath] = true
+				}
+				tp.structNames = append(tp.structNames, ts.Name.Name)
+			}
+		}
+		if len(tp.structNames) > 0 {
+			tagged = append(tagged, tp)
+		}
+	}
+	if len(tagged) == 0 {
+		return nil, errors.New("no structs found")
+	}
+	return tagged, nil
+}
+
+// format returns the source of one of the package's files, with its
+// comments and formatting preserved.
+func (tp taggedPackage) format(srcPath string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, tp.fset, tp.pkg.Files[srcPath]); err != nil {
+		return nil, fmt.Errorf("couldn't format %s: %w", srcPath, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// runInit adds debugmap tags and a //go:generate line for the named structs.
+func runInit(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 2 {
+		cmd.usageError(fs, "must specify a package directory and the structs to initialize")
+	}
+	structFilter := make(map[string]struct{}, fs.NArg()-1)
+	for _, structName := range fs.Args()[1:] {
+		structFilter[structName] = struct{}{}
+	}
+
+	tagged, err := tagPackageStructs(fs.Arg(0), flagConfig, structFilter, false, func(pkgName, structName string, fields []string) {
+		fmt.Printf("Tagged %s.%s: %s\n", pkgName, structName, strings.Join(fields, ", "))
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, tp := range tagged {
+		// The go:generate line goes into the first file with one of the
+		// structs, unless the package already runs optgen
+		needsGenerateLine := true
+		for _, f := range tp.pkg.Files {
+			if hasOptgenGenerateLine(f) {
+				needsGenerateLine = false
+			}
+		}
+
+		for i, srcPath := range tp.structFiles {
+			addLine := needsGenerateLine && i == 0
+			if !tp.tagged[srcPath] && !addLine {
+				continue
+			}
+
+			content, err := tp.format(srcPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if addLine {
+				line := generateLine(fs, tp.structNames)
+				content, err = insertAfterPackageClause(content, line)
+				if err != nil {
+					log.Fatalf("couldn't add go:generate line to %s: %v", srcPath, err)
+				}
+				fmt.Printf("Added %q to %s\n", line, srcPath)
+			}
+			if _, err := writeFileIfChanged(srcPath, content); err != nil {
+				log.Fatalf("couldn't write %s: %v", srcPath, err)
+			}
+		}
+	}
+}
+
+// runTags adds debugmap tags to the untagged fields of structs in place, or
+// with -dry-run prints the changes as a diff.
+func runTags(cmd *command, args []string) {
+	fs := cmd.flagSet()
+	configFlags := addConfigFlags(fs)
+	allFlag := fs.Bool(
+		"all",
+		false,
+		"Tag every struct annotated with an //optgen:generate directive",
+	)
+	dryRunFlag := fs.Bool(
+		"dry-run",
+		false,
+		"Print a unified diff of the changes instead of writing them",
+	)
+	cmd.parse(fs, args)
+
+	flagConfig, err := configFlags.config(fs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 1 || (fs.NArg() < 2 && !*allFlag) {
+		cmd.usageError(fs, "must specify a package directory and the structs to tag, or -all")
+	}
+	structFilter := make(map[string]struct{}, fs.NArg()-1)
+	for _, structName := range fs.Args()[1:] {
+		structFilter[structName] = struct{}{}
+	}
+
+	// With -dry-run, stdout is reserved for the diff
+	progress := io.Writer(os.Stdout)
+	if *dryRunFlag {
+		progress = os.Stderr
+	}
+	tagged, err := tagPackageStructs(fs.Arg(0), flagConfig, structFilter, *allFlag, func(pkgName, structName string, fields []string) {
+		fmt.Fprintf(progress, "Tagged %s.%s: %s\n", pkgName, structName, strings.Join(fields, ", "))
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, tp := range tagged {
+		for _, srcPath := range tp.structFiles {
+			if !tp.tagged[srcPath] {
+				continue
+			}
+			content, err := tp.format(srcPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if *dryRunFlag {
+				existing, err := os.ReadFile(srcPath)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Print(unifiedDiff(srcPath, srcPath, existing, content))
+				continue
+			}
+			if _, err := writeFileIfChanged(srcPath, content); err != nil {
+				log.Fatalf("couldn't write %s: %v", srcPath, err)
+			}
+		}
+	}
+}
+
+// describe summarizes the tag status for "optgen list", given the struct's
+// policy for untagged fields.
+func (s debugMapTagStatus) describe(debugMapDefault string) string {
+	switch {
+	case len(s.Invalid) > 0:
+		return "invalid struct tags: " + strings.Join(s.Invalid, ", ")
+	case len(s.Missing) == 0:
+		return "ok"
+	case debugMapDefault != debugmap.DefaultError:
+		return fmt.Sprintf("untagged fields default to %s: %s", debugMapDefault, strings.Join(s.Missing, ", "))
+	default:
+		return "missing tags: " + strings.Join(s.Missing, ", ")
+	}
+}
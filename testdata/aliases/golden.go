@@ -0,0 +1,234 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=552a0c485ba56236 settings=3a847f37cc2bebc6 body=2488e3bd1509379f
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
+
+type RenamedConfigOption func(r *RenamedConfig)
+
+// NewRenamedConfigWithOptions creates a new RenamedConfig with the passed in options set
+func NewRenamedConfigWithOptions(opts ...RenamedConfigOption) *RenamedConfig {
+	r := &RenamedConfig{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NewRenamedConfigWithOptionsAndDefaults creates a new RenamedConfig with the passed in options set starting from the defaults
+func NewRenamedConfigWithOptionsAndDefaults(opts ...RenamedConfigOption) *RenamedConfig {
+	r := &RenamedConfig{}
+	defaults.MustSet(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ToOption returns a new RenamedConfigOption that sets the values from the passed in RenamedConfig
+func (r *RenamedConfig) ToOption() RenamedConfigOption {
+	return func(to *RenamedConfig) {
+		to.ListenAddr = r.ListenAddr
+		to.Upstreams = r.Upstreams
+		to.Labels = r.Labels
+		to.Legacy = r.Legacy
+	}
+}
+
+// DebugMap returns a map form of RenamedConfig for debugging
+func (r *RenamedConfig) DebugMap() map[string]any {
+	debugMap, _ := r.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of RenamedConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (r *RenamedConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(r).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if r.ListenAddr == "" {
+		debugMap["ListenAddr"] = "(empty)"
+	} else {
+		debugMap["ListenAddr"] = r.ListenAddr
+	}
+	if r.Upstreams == nil {
+		debugMap["Upstreams"] = "nil"
+	} else {
+		debugUpstreams := make([]any, 0, len(r.Upstreams))
+		for _, v := range r.Upstreams {
+			if v == "" {
+				debugUpstreams = append(debugUpstreams, "(empty)")
+			} else {
+				debugUpstreams = append(debugUpstreams, v)
+			}
+		}
+		debugMap["Upstreams"] = debugUpstreams
+	}
+	if r.Labels == nil {
+		debugMap["Labels"] = "nil"
+	} else {
+		debugLabels := make(map[string]any, len(r.Labels))
+		for k, v := range r.Labels {
+			if v == "" {
+				debugLabels[k] = "(empty)"
+			} else {
+				debugLabels[k] = v
+			}
+		}
+		debugMap["Labels"] = debugLabels
+	}
+	debugMap["Legacy"] = r.Legacy
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of RenamedConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (r *RenamedConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(r.DebugMap())
+}
+
+// RenamedConfigWithOptions configures an existing RenamedConfig with the passed in options set
+func RenamedConfigWithOptions(r *RenamedConfig, opts ...RenamedConfigOption) *RenamedConfig {
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithOptions configures the receiver RenamedConfig with the passed in options set
+func (r *RenamedConfig) WithOptions(opts ...RenamedConfigOption) *RenamedConfig {
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithListenAddr returns an option that can set ListenAddr on a RenamedConfig
+//
+// ListenAddr is the address to listen on.
+func WithListenAddr(listenAddr string) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.ListenAddr = listenAddr
+	}
+}
+
+// WithAddr is the name WithListenAddr had before Addr was renamed to ListenAddr
+//
+// Deprecated: use WithListenAddr instead.
+func WithAddr(addr string) RenamedConfigOption {
+	return WithListenAddr(addr)
+}
+
+// WithAddress is the name WithListenAddr had before Address was renamed to ListenAddr
+//
+// Deprecated: use WithListenAddr instead.
+func WithAddress(address string) RenamedConfigOption {
+	return WithListenAddr(address)
+}
+
+// WithUpstreams returns an option that can append Upstreamss to RenamedConfig.Upstreams
+func WithUpstreams(upstreams string) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.Upstreams = append(r.Upstreams, upstreams)
+	}
+}
+
+// SetUpstreams returns an option that can set Upstreams on a RenamedConfig
+func SetUpstreams(upstreams []string) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.Upstreams = upstreams
+	}
+}
+
+// WithBackends is the name WithUpstreams had before Backends was renamed to Upstreams
+//
+// Deprecated: use WithUpstreams instead, Backends were renamed in v2.
+func WithBackends(backends string) RenamedConfigOption {
+	return WithUpstreams(backends)
+}
+
+// SetBackends is the name SetUpstreams had before Backends was renamed to Upstreams
+//
+// Deprecated: use WithUpstreams instead, Backends were renamed in v2.
+func SetBackends(backends []string) RenamedConfigOption {
+	return SetUpstreams(backends)
+}
+
+// WithLabels returns an option that can append Labelss to RenamedConfig.Labels
+func WithLabels(key string, value string) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.Labels[key] = value
+	}
+}
+
+// SetLabels returns an option that can set Labels on a RenamedConfig
+func SetLabels(labels map[string]string) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.Labels = labels
+	}
+}
+
+// WithTags is the name WithLabels had before Tags was renamed to Labels
+//
+// Deprecated: use WithLabels instead.
+func WithTags(key string, value string) RenamedConfigOption {
+	return WithLabels(key, value)
+}
+
+// SetTags is the name SetLabels had before Tags was renamed to Labels
+//
+// Deprecated: use SetLabels instead.
+func SetTags(tags map[string]string) RenamedConfigOption {
+	return SetLabels(tags)
+}
+
+// WithLegacy returns an option that can set Legacy on a RenamedConfig
+//
+// Legacy is no longer used.
+//
+// Deprecated: it has no effect.
+func WithLegacy(legacy bool) RenamedConfigOption {
+	return func(r *RenamedConfig) {
+		r.Legacy = legacy
+	}
+}
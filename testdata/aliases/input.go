@@ -0,0 +1,14 @@
+package testdata
+
+// RenamedConfig has fields that were renamed, whose former options are kept
+// as deprecated aliases.
+type RenamedConfig struct {
+	// ListenAddr is the address to listen on.
+	ListenAddr string `debugmap:"visible" optgen:"alias=Addr,alias=Address"`
+
+	Upstreams []string          `debugmap:"visible-format" optgen:"alias=Backends,deprecated=use WithUpstreams instead, Backends were renamed in v2."`
+	Labels    map[string]string `debugmap:"visible-format" optgen:"alias=Tags"`
+
+	// Legacy is no longer used.
+	Legacy bool `debugmap:"visible" optgen:"deprecated=it has no effect."`
+}
@@ -1,7 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=e406474764e0a44e settings=e2d4ba91b31bcfd5 body=3c7110423dda0de2
 package testdata
 
-import defaults "github.com/creasty/defaults"
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
 
 type BasicConfigOption func(b *BasicConfig)
 
@@ -36,6 +41,22 @@ func (b *BasicConfig) ToOption() BasicConfigOption {
 
 // DebugMap returns a map form of BasicConfig for debugging
 func (b *BasicConfig) DebugMap() map[string]any {
+	debugMap, _ := b.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of BasicConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (b *BasicConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(b).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if b.Name == "" {
 		debugMap["Name"] = "(empty)"
@@ -57,14 +78,27 @@ func (b *BasicConfig) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of BasicConfig for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (b *BasicConfig) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
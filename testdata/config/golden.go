@@ -0,0 +1,328 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=084b9ff6e3a06d3a settings=04df1b02949de020 body=5fe2da3cbd426aff
+package config
+
+import (
+	"fmt"
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
+
+type PrefixedOption func(p *Prefixed)
+
+// NewPrefixedWithOptions creates a new Prefixed with the passed in options set
+func NewPrefixedWithOptions(opts ...PrefixedOption) *Prefixed {
+	p := &Prefixed{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewPrefixedWithOptionsAndDefaults creates a new Prefixed with the passed in options set starting from the defaults
+func NewPrefixedWithOptionsAndDefaults(opts ...PrefixedOption) *Prefixed {
+	p := &Prefixed{}
+	defaults.MustSet(p)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ToOption returns a new PrefixedOption that sets the values from the passed in Prefixed
+func (p *Prefixed) ToOption() PrefixedOption {
+	return func(to *Prefixed) {
+		to.Name = p.Name
+		to.Token = p.Token
+	}
+}
+
+// DebugMap returns a map form of Prefixed for debugging
+func (p *Prefixed) DebugMap() map[string]any {
+	debugMap, _ := p.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Prefixed for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (p *Prefixed) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(p).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if p.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = p.Name
+	}
+	if p.Token == "" {
+		debugMap["Token"] = "(empty)"
+	} else {
+		debugMap["Token"] = p.Token
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Prefixed for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (p *Prefixed) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(p.DebugMap())
+}
+
+// PrefixedWithOptions configures an existing Prefixed with the passed in options set
+func PrefixedWithOptions(p *Prefixed, opts ...PrefixedOption) *Prefixed {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithOptions configures the receiver Prefixed with the passed in options set
+func (p *Prefixed) WithOptions(opts ...PrefixedOption) *Prefixed {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithPrefixedName returns an option that can set Name on a Prefixed
+func WithPrefixedName(name string) PrefixedOption {
+	return func(p *Prefixed) {
+		p.Name = name
+	}
+}
+
+// WithPrefixedToken returns an option that can set Token on a Prefixed
+func WithPrefixedToken(token string) PrefixedOption {
+	return func(p *Prefixed) {
+		p.Token = token
+	}
+}
+
+type RenamedOption func(r *Renamed)
+
+// NewRenamedWithOptions creates a new Renamed with the passed in options set
+func NewRenamedWithOptions(opts ...RenamedOption) *Renamed {
+	r := &Renamed{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NewRenamedWithOptionsAndDefaults creates a new Renamed with the passed in options set starting from the defaults
+func NewRenamedWithOptionsAndDefaults(opts ...RenamedOption) *Renamed {
+	r := &Renamed{}
+	defaults.MustSet(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ToOption returns a new RenamedOption that sets the values from the passed in Renamed
+func (r *Renamed) ToOption() RenamedOption {
+	return func(to *Renamed) {
+		to.Name = r.Name
+		to.Tags = r.Tags
+	}
+}
+
+// DebugMap returns a map form of Renamed for debugging
+func (r *Renamed) DebugMap() map[string]any {
+	debugMap, _ := r.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Renamed for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (r *Renamed) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(r).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if r.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = r.Name
+	}
+	if r.Tags == nil {
+		debugMap["Tags"] = "nil"
+	} else {
+		debugMap["Tags"] = fmt.Sprintf("(slice of size %d)", len(r.Tags))
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Renamed for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (r *Renamed) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(r.DebugMap())
+}
+
+// RenamedWithOptions configures an existing Renamed with the passed in options set
+func RenamedWithOptions(r *Renamed, opts ...RenamedOption) *Renamed {
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithOptions configures the receiver Renamed with the passed in options set
+func (r *Renamed) WithOptions(opts ...RenamedOption) *Renamed {
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithNameForRenamed returns an option that can set Name on a Renamed
+func WithNameForRenamed(name string) RenamedOption {
+	return func(r *Renamed) {
+		r.Name = name
+	}
+}
+
+// WithTagsForRenamed returns an option that can append Tagss to Renamed.Tags
+func WithTagsForRenamed(tags string) RenamedOption {
+	return func(r *Renamed) {
+		r.Tags = append(r.Tags, tags)
+	}
+}
+
+// SetTagsForRenamed returns an option that can set Tags on a Renamed
+func SetTagsForRenamed(tags []string) RenamedOption {
+	return func(r *Renamed) {
+		r.Tags = tags
+	}
+}
+
+// DebugMap returns a map form of DebugOnly for debugging
+func (d *DebugOnly) DebugMap() map[string]any {
+	debugMap, _ := d.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of DebugOnly for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (d *DebugOnly) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(d).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if d.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = d.Name
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of DebugOnly for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (d *DebugOnly) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(d.DebugMap())
+}
@@ -0,0 +1,18 @@
+package config
+
+// Prefixed uses the package settings from optgen.yaml.
+type Prefixed struct {
+	Name  string `debugmap:"visible"`
+	Token string `debugmap:"visible"`
+}
+
+// Renamed uses a per-struct option name template.
+type Renamed struct {
+	Name string   `debugmap:"visible"`
+	Tags []string `debugmap:"visible"`
+}
+
+// DebugOnly only gets DebugMap methods.
+type DebugOnly struct {
+	Name string `debugmap:"visible"`
+}
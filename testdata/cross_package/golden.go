@@ -1,8 +1,11 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=657e58b3d73793dd settings=5e3bbddb43bcea79 body=ba38dc6ed77645e9
 package testdata
 
 import (
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 	"time"
 )
 
@@ -38,6 +41,22 @@ func (c *CrossPackage) ToOption() CrossPackageOption {
 
 // DebugMap returns a map form of CrossPackage for debugging
 func (c *CrossPackage) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of CrossPackage for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *CrossPackage) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if c.Name == "" {
 		debugMap["Name"] = "(empty)"
@@ -45,6 +64,10 @@ func (c *CrossPackage) DebugMap() map[string]any {
 		debugMap["Name"] = c.Name
 	}
 	if dm, ok := any(&c.Timestamp).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Timestamp"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&c.Timestamp).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["Timestamp"] = dm.DebugMap()
@@ -52,6 +75,10 @@ func (c *CrossPackage) DebugMap() map[string]any {
 		debugMap["Timestamp"] = c.Timestamp
 	}
 	if dm, ok := any(&c.Duration).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Duration"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&c.Duration).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["Duration"] = dm.DebugMap()
@@ -62,14 +89,27 @@ func (c *CrossPackage) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of CrossPackage for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (c *CrossPackage) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -0,0 +1,429 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=66cf16f62ce5d4cd settings=d522bac4b3c16e11 body=b69e8f680fe1bfcb
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
+
+type ParentOption func(p *Parent)
+
+// NewParentWithOptions creates a new Parent with the passed in options set
+func NewParentWithOptions(opts ...ParentOption) *Parent {
+	p := &Parent{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewParentWithOptionsAndDefaults creates a new Parent with the passed in options set starting from the defaults
+func NewParentWithOptionsAndDefaults(opts ...ParentOption) *Parent {
+	p := &Parent{}
+	defaults.MustSet(p)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ToOption returns a new ParentOption that sets the values from the passed in Parent
+func (p *Parent) ToOption() ParentOption {
+	return func(to *Parent) {
+		to.Title = p.Title
+		to.Children = p.Children
+	}
+}
+
+// DebugMap returns a map form of Parent for debugging
+func (p *Parent) DebugMap() map[string]any {
+	debugMap, _ := p.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Parent for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (p *Parent) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(p).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if p.Title == "" {
+		debugMap["Title"] = "(empty)"
+	} else {
+		debugMap["Title"] = p.Title
+	}
+	if p.Children == nil {
+		debugMap["Children"] = "nil"
+	} else {
+		debugChildren := make([]any, 0, len(p.Children))
+		for _, v := range p.Children {
+			if v == nil {
+				debugChildren = append(debugChildren, "nil")
+			} else if _, ok := seen[reflect.ValueOf(v).Pointer()]; ok {
+				debugChildren = append(debugChildren, "(cycle)")
+			} else if dm, ok := any(v).(interface {
+				debugMapWithState(int, map[uintptr]struct{}) any
+			}); ok {
+				debugChildren = append(debugChildren, dm.debugMapWithState(depth+1, seen))
+			} else if dm, ok := any(v).(interface {
+				DebugMap() map[string]any
+			}); ok {
+				debugChildren = append(debugChildren, dm.DebugMap())
+			} else {
+				debugChildren = append(debugChildren, *v)
+			}
+		}
+		debugMap["Children"] = debugChildren
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Parent for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (p *Parent) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(p.DebugMap())
+}
+
+// ParentWithOptions configures an existing Parent with the passed in options set
+func ParentWithOptions(p *Parent, opts ...ParentOption) *Parent {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithOptions configures the receiver Parent with the passed in options set
+func (p *Parent) WithOptions(opts ...ParentOption) *Parent {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithTitle returns an option that can set Title on a Parent
+func WithTitle(title string) ParentOption {
+	return func(p *Parent) {
+		p.Title = title
+	}
+}
+
+// WithChildren returns an option that can append Childrens to Parent.Children
+func WithChildren(children *Child) ParentOption {
+	return func(p *Parent) {
+		p.Children = append(p.Children, children)
+	}
+}
+
+// SetChildren returns an option that can set Children on a Parent
+func SetChildren(children []*Child) ParentOption {
+	return func(p *Parent) {
+		p.Children = children
+	}
+}
+
+type ChildOption func(c *Child)
+
+// NewChildWithOptions creates a new Child with the passed in options set
+func NewChildWithOptions(opts ...ChildOption) *Child {
+	c := &Child{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewChildWithOptionsAndDefaults creates a new Child with the passed in options set starting from the defaults
+func NewChildWithOptionsAndDefaults(opts ...ChildOption) *Child {
+	c := &Child{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new ChildOption that sets the values from the passed in Child
+func (c *Child) ToOption() ChildOption {
+	return func(to *Child) {
+		to.Label = c.Label
+		to.Parent = c.Parent
+	}
+}
+
+// DebugMap returns a map form of Child for debugging
+func (c *Child) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Child for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *Child) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.Label == "" {
+		debugMap["Label"] = "(empty)"
+	} else {
+		debugMap["Label"] = c.Label
+	}
+	if c.Parent == nil {
+		debugMap["Parent"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(c.Parent).Pointer()]; ok {
+		debugMap["Parent"] = "(cycle)"
+	} else if dm, ok := any(c.Parent).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Parent"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(c.Parent).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Parent"] = dm.DebugMap()
+	} else {
+		debugMap["Parent"] = *c.Parent
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Child for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *Child) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// ChildWithOptions configures an existing Child with the passed in options set
+func ChildWithOptions(c *Child, opts ...ChildOption) *Child {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver Child with the passed in options set
+func (c *Child) WithOptions(opts ...ChildOption) *Child {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithLabel returns an option that can set Label on a Child
+func WithLabel(label string) ChildOption {
+	return func(c *Child) {
+		c.Label = label
+	}
+}
+
+// WithParent returns an option that can set Parent on a Child
+func WithParent(parent *Parent) ChildOption {
+	return func(c *Child) {
+		c.Parent = parent
+	}
+}
+
+type ChainOption func(c *Chain)
+
+// NewChainWithOptions creates a new Chain with the passed in options set
+func NewChainWithOptions(opts ...ChainOption) *Chain {
+	c := &Chain{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewChainWithOptionsAndDefaults creates a new Chain with the passed in options set starting from the defaults
+func NewChainWithOptionsAndDefaults(opts ...ChainOption) *Chain {
+	c := &Chain{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new ChainOption that sets the values from the passed in Chain
+func (c *Chain) ToOption() ChainOption {
+	return func(to *Chain) {
+		to.Value = c.Value
+		to.Next = c.Next
+	}
+}
+
+// DebugMap returns a map form of Chain for debugging
+func (c *Chain) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Chain for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *Chain) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.Value == "" {
+		debugMap["Value"] = "(empty)"
+	} else {
+		debugMap["Value"] = c.Value
+	}
+	if c.Next == nil {
+		debugMap["Next"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(c.Next).Pointer()]; ok {
+		debugMap["Next"] = "(cycle)"
+	} else if dm, ok := any(c.Next).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Next"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(c.Next).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Next"] = dm.DebugMap()
+	} else {
+		debugMap["Next"] = *c.Next
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of Chain for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *Chain) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// ChainWithOptions configures an existing Chain with the passed in options set
+func ChainWithOptions(c *Chain, opts ...ChainOption) *Chain {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver Chain with the passed in options set
+func (c *Chain) WithOptions(opts ...ChainOption) *Chain {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithValue returns an option that can set Value on a Chain
+func WithValue(value string) ChainOption {
+	return func(c *Chain) {
+		c.Value = value
+	}
+}
+
+// WithNext returns an option that can set Next on a Chain
+func WithNext(next *Chain) ChainOption {
+	return func(c *Chain) {
+		c.Next = next
+	}
+}
@@ -0,0 +1,19 @@
+package testdata
+
+// Parent holds children that point back at it.
+type Parent struct {
+	Title    string   `debugmap:"visible"`
+	Children []*Child `debugmap:"visible-format"`
+}
+
+// Child has a back-pointer to its Parent.
+type Child struct {
+	Label  string  `debugmap:"visible"`
+	Parent *Parent `debugmap:"visible"`
+}
+
+// Chain is a linked list that can nest arbitrarily deep.
+type Chain struct {
+	Value string `debugmap:"visible"`
+	Next  *Chain `debugmap:"visible"`
+}
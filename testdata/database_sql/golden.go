@@ -1,9 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=cb04070e4218a999 settings=af47b9be95076b78 body=11bd635848c3e4f6
 package testdata
 
 import (
 	"database/sql"
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 )
 
 type DatabaseConfigOption func(d *DatabaseConfig)
@@ -38,9 +41,29 @@ func (d *DatabaseConfig) ToOption() DatabaseConfigOption {
 
 // DebugMap returns a map form of DatabaseConfig for debugging
 func (d *DatabaseConfig) DebugMap() map[string]any {
+	debugMap, _ := d.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of DatabaseConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (d *DatabaseConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(d).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	debugMap["ConnectionString"] = "(sensitive)"
 	if dm, ok := any(&d.MaxConnections).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["MaxConnections"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&d.MaxConnections).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["MaxConnections"] = dm.DebugMap()
@@ -52,14 +75,27 @@ func (d *DatabaseConfig) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of DatabaseConfig for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (d *DatabaseConfig) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -89,6 +125,8 @@ func (d *DatabaseConfig) WithOptions(opts ...DatabaseConfigOption) *DatabaseConf
 }
 
 // WithConnectionString returns an option that can set ConnectionString on a DatabaseConfig
+//
+// ConnectionString is sensitive: DebugMap shows it as "(sensitive)".
 func WithConnectionString(connectionString sql.NullString) DatabaseConfigOption {
 	return func(d *DatabaseConfig) {
 		d.ConnectionString = connectionString
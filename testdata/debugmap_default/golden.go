@@ -0,0 +1,141 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=17e1b8d2d132524b settings=2a72212758af166d body=3d5dd6cfd0080898
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
+
+type LegacyConfigOption func(l *LegacyConfig)
+
+// NewLegacyConfigWithOptions creates a new LegacyConfig with the passed in options set
+func NewLegacyConfigWithOptions(opts ...LegacyConfigOption) *LegacyConfig {
+	l := &LegacyConfig{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewLegacyConfigWithOptionsAndDefaults creates a new LegacyConfig with the passed in options set starting from the defaults
+func NewLegacyConfigWithOptionsAndDefaults(opts ...LegacyConfigOption) *LegacyConfig {
+	l := &LegacyConfig{}
+	defaults.MustSet(l)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// ToOption returns a new LegacyConfigOption that sets the values from the passed in LegacyConfig
+func (l *LegacyConfig) ToOption() LegacyConfigOption {
+	return func(to *LegacyConfig) {
+		to.Name = l.Name
+		to.Internal = l.Internal
+		to.Password = l.Password
+	}
+}
+
+// DebugMap returns a map form of LegacyConfig for debugging
+func (l *LegacyConfig) DebugMap() map[string]any {
+	debugMap, _ := l.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of LegacyConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (l *LegacyConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(l).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if l.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = l.Name
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of LegacyConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (l *LegacyConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(l.DebugMap())
+}
+
+// LegacyConfigWithOptions configures an existing LegacyConfig with the passed in options set
+func LegacyConfigWithOptions(l *LegacyConfig, opts ...LegacyConfigOption) *LegacyConfig {
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithOptions configures the receiver LegacyConfig with the passed in options set
+func (l *LegacyConfig) WithOptions(opts ...LegacyConfigOption) *LegacyConfig {
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithName returns an option that can set Name on a LegacyConfig
+func WithName(name string) LegacyConfigOption {
+	return func(l *LegacyConfig) {
+		l.Name = name
+	}
+}
+
+// WithInternal returns an option that can set Internal on a LegacyConfig
+//
+// DebugMap leaves Internal out.
+func WithInternal(internal string) LegacyConfigOption {
+	return func(l *LegacyConfig) {
+		l.Internal = internal
+	}
+}
+
+// WithPassword returns an option that can set Password on a LegacyConfig
+//
+// DebugMap leaves Password out.
+func WithPassword(password string) LegacyConfigOption {
+	return func(l *LegacyConfig) {
+		l.Password = password
+	}
+}
@@ -0,0 +1,10 @@
+package testdata
+
+// LegacyConfig adopts optgen without tagging every field.
+//
+//optgen:debugmap-default=hidden
+type LegacyConfig struct {
+	Name     string `debugmap:"visible"`
+	Internal string
+	Password string
+}
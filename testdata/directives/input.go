@@ -0,0 +1,22 @@
+package directives
+
+// Annotated is picked up by -all and written next to this file.
+//
+//optgen:generate
+type Annotated struct {
+	Name string `debugmap:"visible"`
+}
+
+// ServerSettings is picked up by -all with its own output file and prefixed
+// option names.
+//
+//optgen:generate prefix=true output=server_options.go
+type ServerSettings struct {
+	Name string `debugmap:"visible"`
+	Port int    `debugmap:"visible"`
+}
+
+// NotAnnotated is only generated when named explicitly.
+type NotAnnotated struct {
+	Name string `debugmap:"visible"`
+}
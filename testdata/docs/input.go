@@ -0,0 +1,25 @@
+package docs
+
+import "time"
+
+// ServerConfig configures the API server.
+//
+//optgen:generate
+type ServerConfig struct {
+	// Name identifies the server
+	// in logs.
+	Name string `debugmap:"visible"`
+
+	Port    uint16        `debugmap:"visible" default:"8080"` // Port to listen on
+	Timeout time.Duration `debugmap:"visible" default:"30s"`
+
+	// Hosts are the names the server answers to, e.g. "a|b".
+	Hosts  []string          `debugmap:"visible-format" default:"[\"localhost\"]"`
+	Labels map[string]string `debugmap:"visible-format"`
+
+	// Password authenticates <admin> clients.
+	Password string `debugmap:"sensitive"`
+	Upstream string `debugmap:"url-redacted"`
+	Internal string `debugmap:"hidden"`
+	internal string
+}
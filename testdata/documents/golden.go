@@ -0,0 +1,824 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=59dfbbe01f3427d9 settings=0d46c23f10da8b6f body=0647415017571a77
+package testdata
+
+import (
+	"fmt"
+	defaults "github.com/creasty/defaults"
+	decode "github.com/ecordell/optgen/decode"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type ServiceConfigOption func(s *ServiceConfig)
+
+// NewServiceConfigWithOptions creates a new ServiceConfig with the passed in options set
+func NewServiceConfigWithOptions(opts ...ServiceConfigOption) *ServiceConfig {
+	s := &ServiceConfig{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewServiceConfigWithOptionsAndDefaults creates a new ServiceConfig with the passed in options set starting from the defaults
+func NewServiceConfigWithOptionsAndDefaults(opts ...ServiceConfigOption) *ServiceConfig {
+	s := &ServiceConfig{}
+	defaults.MustSet(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ToOption returns a new ServiceConfigOption that sets the values from the passed in ServiceConfig
+func (s *ServiceConfig) ToOption() ServiceConfigOption {
+	return func(to *ServiceConfig) {
+		to.Name = s.Name
+		to.Port = s.Port
+		to.Debug = s.Debug
+		to.Mode = s.Mode
+		to.Ratio = s.Ratio
+		to.Retries = s.Retries
+		to.Timeout = s.Timeout
+		to.Hosts = s.Hosts
+		to.Weights = s.Weights
+		to.Labels = s.Labels
+		to.Store = s.Store
+		to.Cache = s.Cache
+		to.Password = s.Password
+		to.Untagged = s.Untagged
+	}
+}
+
+// DebugMap returns a map form of ServiceConfig for debugging
+func (s *ServiceConfig) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of ServiceConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *ServiceConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if s.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = s.Name
+	}
+	debugMap["Port"] = s.Port
+	debugMap["Debug"] = s.Debug
+	if dm, ok := any(&s.Mode).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Mode"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&s.Mode).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Mode"] = dm.DebugMap()
+	} else {
+		debugMap["Mode"] = s.Mode
+	}
+	debugMap["Ratio"] = s.Ratio
+	if s.Retries == nil {
+		debugMap["Retries"] = "nil"
+	} else if dm, ok := any(s.Retries).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Retries"] = dm.DebugMap()
+	} else {
+		debugMap["Retries"] = *s.Retries
+	}
+	if dm, ok := any(&s.Timeout).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Timeout"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&s.Timeout).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Timeout"] = dm.DebugMap()
+	} else {
+		debugMap["Timeout"] = s.Timeout
+	}
+	if s.Hosts == nil {
+		debugMap["Hosts"] = "nil"
+	} else {
+		debugMap["Hosts"] = fmt.Sprintf("(slice of size %d)", len(s.Hosts))
+	}
+	if s.Weights == nil {
+		debugMap["Weights"] = "nil"
+	} else {
+		debugMap["Weights"] = fmt.Sprintf("(slice of size %d)", len(s.Weights))
+	}
+	if s.Labels == nil {
+		debugMap["Labels"] = "nil"
+	} else {
+		debugMap["Labels"] = fmt.Sprintf("(map of size %d)", len(s.Labels))
+	}
+	if dm, ok := any(&s.Store).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Store"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&s.Store).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Store"] = dm.DebugMap()
+	} else {
+		debugMap["Store"] = s.Store
+	}
+	if s.Cache == nil {
+		debugMap["Cache"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(s.Cache).Pointer()]; ok {
+		debugMap["Cache"] = "(cycle)"
+	} else if dm, ok := any(s.Cache).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Cache"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(s.Cache).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Cache"] = dm.DebugMap()
+	} else {
+		debugMap["Cache"] = *s.Cache
+	}
+	if s.Password == "" {
+		debugMap["Password"] = "(empty)"
+	} else {
+		debugMap["Password"] = "(sensitive)"
+	}
+	if s.Untagged == "" {
+		debugMap["Untagged"] = "(empty)"
+	} else {
+		debugMap["Untagged"] = s.Untagged
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of ServiceConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (s *ServiceConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(s.DebugMap())
+}
+
+// ServiceConfigWithOptions configures an existing ServiceConfig with the passed in options set
+func ServiceConfigWithOptions(s *ServiceConfig, opts ...ServiceConfigOption) *ServiceConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithOptions configures the receiver ServiceConfig with the passed in options set
+func (s *ServiceConfig) WithOptions(opts ...ServiceConfigOption) *ServiceConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithServiceConfigName returns an option that can set Name on a ServiceConfig
+func WithServiceConfigName(name string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Name = name
+	}
+}
+
+// WithServiceConfigPort returns an option that can set Port on a ServiceConfig
+func WithServiceConfigPort(port uint16) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Port = port
+	}
+}
+
+// WithServiceConfigDebug returns an option that can set Debug on a ServiceConfig
+func WithServiceConfigDebug(debug bool) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Debug = debug
+	}
+}
+
+// WithServiceConfigMode returns an option that can set Mode on a ServiceConfig
+func WithServiceConfigMode(mode Mode) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Mode = mode
+	}
+}
+
+// WithServiceConfigRatio returns an option that can set Ratio on a ServiceConfig
+func WithServiceConfigRatio(ratio float64) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Ratio = ratio
+	}
+}
+
+// WithServiceConfigRetries returns an option that can set Retries on a ServiceConfig
+func WithServiceConfigRetries(retries *int) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Retries = retries
+	}
+}
+
+// WithServiceConfigTimeout returns an option that can set Timeout on a ServiceConfig
+func WithServiceConfigTimeout(timeout time.Duration) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Timeout = timeout
+	}
+}
+
+// WithServiceConfigHosts returns an option that can append Hostss to ServiceConfig.Hosts
+func WithServiceConfigHosts(hosts string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Hosts = append(s.Hosts, hosts)
+	}
+}
+
+// SetServiceConfigHosts returns an option that can set Hosts on a ServiceConfig
+func SetServiceConfigHosts(hosts []string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Hosts = hosts
+	}
+}
+
+// WithServiceConfigWeights returns an option that can append Weightss to ServiceConfig.Weights
+func WithServiceConfigWeights(weights float32) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Weights = append(s.Weights, weights)
+	}
+}
+
+// SetServiceConfigWeights returns an option that can set Weights on a ServiceConfig
+func SetServiceConfigWeights(weights []float32) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Weights = weights
+	}
+}
+
+// WithServiceConfigLabels returns an option that can append Labelss to ServiceConfig.Labels
+func WithServiceConfigLabels(key string, value string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Labels[key] = value
+	}
+}
+
+// SetServiceConfigLabels returns an option that can set Labels on a ServiceConfig
+func SetServiceConfigLabels(labels map[string]string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Labels = labels
+	}
+}
+
+// WithServiceConfigStore returns an option that can set Store on a ServiceConfig
+func WithServiceConfigStore(store StoreConfig) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Store = store
+	}
+}
+
+// WithServiceConfigCache returns an option that can set Cache on a ServiceConfig
+func WithServiceConfigCache(cache *CacheConfig) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Cache = cache
+	}
+}
+
+// WithServiceConfigPassword returns an option that can set Password on a ServiceConfig
+//
+// Password is sensitive: DebugMap shows it as "(sensitive)".
+func WithServiceConfigPassword(password string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Password = password
+	}
+}
+
+// WithServiceConfigUntagged returns an option that can set Untagged on a ServiceConfig
+func WithServiceConfigUntagged(untagged string) ServiceConfigOption {
+	return func(s *ServiceConfig) {
+		s.Untagged = untagged
+	}
+}
+
+// ServiceConfigOptionsFromMap returns options for the fields of ServiceConfig that are set in m,
+// a decoded config document keyed by the fields' json or yaml tag names. Values
+// are converted strictly and keys that match no field are an error, so typos
+// aren't silently ignored. Fields missing from m produce no option, so defaults
+// are kept.
+func ServiceConfigOptionsFromMap(m map[string]any) ([]ServiceConfigOption, error) {
+	if err := decode.CheckKeys(m, "name", "port", "listen_port", "debug", "mode", "ratio", "retries", "timeout", "hosts", "weights", "labels", "store", "cache", "Untagged"); err != nil {
+		return nil, err
+	}
+	opts := make([]ServiceConfigOption, 0, len(m))
+	if value, key, ok := decode.Lookup(m, "name"); ok {
+		converted, err := decode.String[string](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Name = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "port", "listen_port"); ok {
+		converted, err := decode.Uint[uint16](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Port = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "debug"); ok {
+		converted, err := decode.Bool[bool](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Debug = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "mode"); ok {
+		converted, err := decode.String[Mode](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Mode = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "ratio"); ok {
+		converted, err := decode.Float[float64](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Ratio = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "retries"); ok {
+		converted, err := decode.Int[int](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Retries = &converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "timeout"); ok {
+		converted, err := decode.Duration[time.Duration](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Timeout = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "hosts"); ok {
+		converted, err := decode.Slice(key, value, decode.String[string])
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Hosts = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "weights"); ok {
+		converted, err := decode.Slice(key, value, decode.Float[float32])
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Weights = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "labels"); ok {
+		converted, err := decode.Map[string](key, value, decode.String[string])
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Labels = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "store"); ok {
+		nested, err := decode.Object(key, value)
+		if err != nil {
+			return nil, err
+		}
+		storeOpts, err := StoreConfigOptionsFromMap(nested)
+		if err != nil {
+			return nil, decode.Within(key, err)
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			for _, opt := range storeOpts {
+				opt(&s.Store)
+			}
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "cache"); ok {
+		nested, err := decode.Object(key, value)
+		if err != nil {
+			return nil, err
+		}
+		cacheOpts, err := CacheConfigOptionsFromMap(nested)
+		if err != nil {
+			return nil, decode.Within(key, err)
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			if s.Cache == nil {
+				s.Cache = &CacheConfig{}
+			}
+			for _, opt := range cacheOpts {
+				opt(s.Cache)
+			}
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "Untagged"); ok {
+		converted, err := decode.String[string](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *ServiceConfig) {
+			s.Untagged = converted
+		})
+	}
+	return opts, nil
+}
+
+// ServiceConfigOptionsFromJSON decodes a JSON document from r and returns its options, see
+// ServiceConfigOptionsFromMap.
+func ServiceConfigOptionsFromJSON(r io.Reader) ([]ServiceConfigOption, error) {
+	m, err := decode.JSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return ServiceConfigOptionsFromMap(m)
+}
+
+// ServiceConfigOptionsFromYAML decodes a YAML document from r and returns its options, see
+// ServiceConfigOptionsFromMap.
+func ServiceConfigOptionsFromYAML(r io.Reader) ([]ServiceConfigOption, error) {
+	m, err := decode.YAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return ServiceConfigOptionsFromMap(m)
+}
+
+type StoreConfigOption func(s *StoreConfig)
+
+// NewStoreConfigWithOptions creates a new StoreConfig with the passed in options set
+func NewStoreConfigWithOptions(opts ...StoreConfigOption) *StoreConfig {
+	s := &StoreConfig{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewStoreConfigWithOptionsAndDefaults creates a new StoreConfig with the passed in options set starting from the defaults
+func NewStoreConfigWithOptionsAndDefaults(opts ...StoreConfigOption) *StoreConfig {
+	s := &StoreConfig{}
+	defaults.MustSet(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ToOption returns a new StoreConfigOption that sets the values from the passed in StoreConfig
+func (s *StoreConfig) ToOption() StoreConfigOption {
+	return func(to *StoreConfig) {
+		to.URL = s.URL
+		to.MaxOpen = s.MaxOpen
+	}
+}
+
+// DebugMap returns a map form of StoreConfig for debugging
+func (s *StoreConfig) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of StoreConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *StoreConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if s.URL == "" {
+		debugMap["URL"] = "(empty)"
+	} else {
+		debugMap["URL"] = s.URL
+	}
+	debugMap["MaxOpen"] = s.MaxOpen
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of StoreConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (s *StoreConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(s.DebugMap())
+}
+
+// StoreConfigWithOptions configures an existing StoreConfig with the passed in options set
+func StoreConfigWithOptions(s *StoreConfig, opts ...StoreConfigOption) *StoreConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithOptions configures the receiver StoreConfig with the passed in options set
+func (s *StoreConfig) WithOptions(opts ...StoreConfigOption) *StoreConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithStoreConfigURL returns an option that can set URL on a StoreConfig
+func WithStoreConfigURL(uRL string) StoreConfigOption {
+	return func(s *StoreConfig) {
+		s.URL = uRL
+	}
+}
+
+// WithStoreConfigMaxOpen returns an option that can set MaxOpen on a StoreConfig
+func WithStoreConfigMaxOpen(maxOpen int32) StoreConfigOption {
+	return func(s *StoreConfig) {
+		s.MaxOpen = maxOpen
+	}
+}
+
+// StoreConfigOptionsFromMap returns options for the fields of StoreConfig that are set in m,
+// a decoded config document keyed by the fields' json or yaml tag names. Values
+// are converted strictly and keys that match no field are an error, so typos
+// aren't silently ignored. Fields missing from m produce no option, so defaults
+// are kept.
+func StoreConfigOptionsFromMap(m map[string]any) ([]StoreConfigOption, error) {
+	if err := decode.CheckKeys(m, "url", "max_open"); err != nil {
+		return nil, err
+	}
+	opts := make([]StoreConfigOption, 0, len(m))
+	if value, key, ok := decode.Lookup(m, "url"); ok {
+		converted, err := decode.String[string](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *StoreConfig) {
+			s.URL = converted
+		})
+	}
+	if value, key, ok := decode.Lookup(m, "max_open"); ok {
+		converted, err := decode.Int[int32](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(s *StoreConfig) {
+			s.MaxOpen = converted
+		})
+	}
+	return opts, nil
+}
+
+// StoreConfigOptionsFromJSON decodes a JSON document from r and returns its options, see
+// StoreConfigOptionsFromMap.
+func StoreConfigOptionsFromJSON(r io.Reader) ([]StoreConfigOption, error) {
+	m, err := decode.JSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return StoreConfigOptionsFromMap(m)
+}
+
+// StoreConfigOptionsFromYAML decodes a YAML document from r and returns its options, see
+// StoreConfigOptionsFromMap.
+func StoreConfigOptionsFromYAML(r io.Reader) ([]StoreConfigOption, error) {
+	m, err := decode.YAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return StoreConfigOptionsFromMap(m)
+}
+
+type CacheConfigOption func(c *CacheConfig)
+
+// NewCacheConfigWithOptions creates a new CacheConfig with the passed in options set
+func NewCacheConfigWithOptions(opts ...CacheConfigOption) *CacheConfig {
+	c := &CacheConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewCacheConfigWithOptionsAndDefaults creates a new CacheConfig with the passed in options set starting from the defaults
+func NewCacheConfigWithOptionsAndDefaults(opts ...CacheConfigOption) *CacheConfig {
+	c := &CacheConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new CacheConfigOption that sets the values from the passed in CacheConfig
+func (c *CacheConfig) ToOption() CacheConfigOption {
+	return func(to *CacheConfig) {
+		to.Size = c.Size
+	}
+}
+
+// DebugMap returns a map form of CacheConfig for debugging
+func (c *CacheConfig) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of CacheConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *CacheConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	debugMap["Size"] = c.Size
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of CacheConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *CacheConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// CacheConfigWithOptions configures an existing CacheConfig with the passed in options set
+func CacheConfigWithOptions(c *CacheConfig, opts ...CacheConfigOption) *CacheConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver CacheConfig with the passed in options set
+func (c *CacheConfig) WithOptions(opts ...CacheConfigOption) *CacheConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithCacheConfigSize returns an option that can set Size on a CacheConfig
+func WithCacheConfigSize(size int) CacheConfigOption {
+	return func(c *CacheConfig) {
+		c.Size = size
+	}
+}
+
+// CacheConfigOptionsFromMap returns options for the fields of CacheConfig that are set in m,
+// a decoded config document keyed by the fields' json or yaml tag names. Values
+// are converted strictly and keys that match no field are an error, so typos
+// aren't silently ignored. Fields missing from m produce no option, so defaults
+// are kept.
+func CacheConfigOptionsFromMap(m map[string]any) ([]CacheConfigOption, error) {
+	if err := decode.CheckKeys(m, "size"); err != nil {
+		return nil, err
+	}
+	opts := make([]CacheConfigOption, 0, len(m))
+	if value, key, ok := decode.Lookup(m, "size"); ok {
+		converted, err := decode.Int[int](key, value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, func(c *CacheConfig) {
+			c.Size = converted
+		})
+	}
+	return opts, nil
+}
+
+// CacheConfigOptionsFromJSON decodes a JSON document from r and returns its options, see
+// CacheConfigOptionsFromMap.
+func CacheConfigOptionsFromJSON(r io.Reader) ([]CacheConfigOption, error) {
+	m, err := decode.JSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return CacheConfigOptionsFromMap(m)
+}
+
+// CacheConfigOptionsFromYAML decodes a YAML document from r and returns its options, see
+// CacheConfigOptionsFromMap.
+func CacheConfigOptionsFromYAML(r io.Reader) ([]CacheConfigOption, error) {
+	m, err := decode.YAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return CacheConfigOptionsFromMap(m)
+}
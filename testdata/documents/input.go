@@ -0,0 +1,36 @@
+package testdata
+
+import "time"
+
+// Mode is a named type converted like its underlying type
+type Mode string
+
+// ServiceConfig is read from config documents
+type ServiceConfig struct {
+	Name     string            `debugmap:"visible" json:"name"`
+	Port     uint16            `debugmap:"visible" json:"port" yaml:"listen_port"`
+	Debug    bool              `debugmap:"visible" json:"debug,omitempty"`
+	Mode     Mode              `debugmap:"visible" json:"mode"`
+	Ratio    float64           `debugmap:"visible" json:"ratio"`
+	Retries  *int              `debugmap:"visible" json:"retries"`
+	Timeout  time.Duration     `debugmap:"visible" json:"timeout"`
+	Hosts    []string          `debugmap:"visible" json:"hosts"`
+	Weights  []float32         `debugmap:"visible" json:"weights"`
+	Labels   map[string]string `debugmap:"visible" json:"labels"`
+	Store    StoreConfig       `debugmap:"visible" json:"store"`
+	Cache    *CacheConfig      `debugmap:"visible" json:"cache"`
+	Password string            `debugmap:"sensitive" json:"-" yaml:"-"`
+	Untagged string            `debugmap:"visible"`
+	internal string
+}
+
+// StoreConfig is nested in ServiceConfig
+type StoreConfig struct {
+	URL     string `debugmap:"visible" json:"url"`
+	MaxOpen int32  `debugmap:"visible" json:"max_open"`
+}
+
+// CacheConfig is nested in ServiceConfig behind a pointer
+type CacheConfig struct {
+	Size int `debugmap:"visible" json:"size"`
+}
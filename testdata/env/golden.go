@@ -0,0 +1,966 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=31f0db50f952a731 settings=f2934eb9eab2ce09 body=3039ff0eb91c7b47
+package testdata
+
+import (
+	"fmt"
+	defaults "github.com/creasty/defaults"
+	"os"
+	"reflect"
+	slices "slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AppConfigOption func(a *AppConfig)
+
+// NewAppConfigWithOptions creates a new AppConfig with the passed in options set
+func NewAppConfigWithOptions(opts ...AppConfigOption) *AppConfig {
+	a := &AppConfig{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// NewAppConfigWithOptionsAndDefaults creates a new AppConfig with the passed in options set starting from the defaults
+func NewAppConfigWithOptionsAndDefaults(opts ...AppConfigOption) *AppConfig {
+	a := &AppConfig{}
+	defaults.MustSet(a)
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// ToOption returns a new AppConfigOption that sets the values from the passed in AppConfig
+func (a *AppConfig) ToOption() AppConfigOption {
+	return func(to *AppConfig) {
+		to.Name = a.Name
+		to.Port = a.Port
+		to.Debug = a.Debug
+		to.Level = a.Level
+		to.Ratio = a.Ratio
+		to.MaxBytes = a.MaxBytes
+		to.Timeout = a.Timeout
+		to.Hosts = a.Hosts
+		to.Ports = a.Ports
+		to.Labels = a.Labels
+		to.Limits = a.Limits
+		to.Database = a.Database
+		to.Cache = a.Cache
+		to.Password = a.Password
+	}
+}
+
+// DebugMap returns a map form of AppConfig for debugging
+func (a *AppConfig) DebugMap() map[string]any {
+	debugMap, _ := a.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of AppConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (a *AppConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(a).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if a.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = a.Name
+	}
+	debugMap["Port"] = a.Port
+	debugMap["Debug"] = a.Debug
+	if dm, ok := any(&a.Level).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Level"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&a.Level).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Level"] = dm.DebugMap()
+	} else {
+		debugMap["Level"] = a.Level
+	}
+	debugMap["Ratio"] = a.Ratio
+	if a.MaxBytes == nil {
+		debugMap["MaxBytes"] = "nil"
+	} else if dm, ok := any(a.MaxBytes).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["MaxBytes"] = dm.DebugMap()
+	} else {
+		debugMap["MaxBytes"] = *a.MaxBytes
+	}
+	if dm, ok := any(&a.Timeout).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Timeout"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&a.Timeout).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Timeout"] = dm.DebugMap()
+	} else {
+		debugMap["Timeout"] = a.Timeout
+	}
+	if a.Hosts == nil {
+		debugMap["Hosts"] = "nil"
+	} else {
+		debugMap["Hosts"] = fmt.Sprintf("(slice of size %d)", len(a.Hosts))
+	}
+	if a.Ports == nil {
+		debugMap["Ports"] = "nil"
+	} else {
+		debugMap["Ports"] = fmt.Sprintf("(slice of size %d)", len(a.Ports))
+	}
+	if a.Labels == nil {
+		debugMap["Labels"] = "nil"
+	} else {
+		debugMap["Labels"] = fmt.Sprintf("(map of size %d)", len(a.Labels))
+	}
+	if a.Limits == nil {
+		debugMap["Limits"] = "nil"
+	} else {
+		debugMap["Limits"] = fmt.Sprintf("(map of size %d)", len(a.Limits))
+	}
+	if dm, ok := any(&a.Database).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Database"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&a.Database).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Database"] = dm.DebugMap()
+	} else {
+		debugMap["Database"] = a.Database
+	}
+	if a.Cache == nil {
+		debugMap["Cache"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(a.Cache).Pointer()]; ok {
+		debugMap["Cache"] = "(cycle)"
+	} else if dm, ok := any(a.Cache).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Cache"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(a.Cache).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Cache"] = dm.DebugMap()
+	} else {
+		debugMap["Cache"] = *a.Cache
+	}
+	if a.Password == "" {
+		debugMap["Password"] = "(empty)"
+	} else {
+		debugMap["Password"] = "(sensitive)"
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of AppConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (a *AppConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(a.DebugMap())
+}
+
+// AppConfigWithOptions configures an existing AppConfig with the passed in options set
+func AppConfigWithOptions(a *AppConfig, opts ...AppConfigOption) *AppConfig {
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithOptions configures the receiver AppConfig with the passed in options set
+func (a *AppConfig) WithOptions(opts ...AppConfigOption) *AppConfig {
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithAppConfigName returns an option that can set Name on a AppConfig
+func WithAppConfigName(name string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Name = name
+	}
+}
+
+// WithAppConfigPort returns an option that can set Port on a AppConfig
+func WithAppConfigPort(port int) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Port = port
+	}
+}
+
+// WithAppConfigDebug returns an option that can set Debug on a AppConfig
+func WithAppConfigDebug(debug bool) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Debug = debug
+	}
+}
+
+// WithAppConfigLevel returns an option that can set Level on a AppConfig
+func WithAppConfigLevel(level Level) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Level = level
+	}
+}
+
+// WithAppConfigRatio returns an option that can set Ratio on a AppConfig
+func WithAppConfigRatio(ratio float32) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Ratio = ratio
+	}
+}
+
+// WithAppConfigMaxBytes returns an option that can set MaxBytes on a AppConfig
+func WithAppConfigMaxBytes(maxBytes *uint64) AppConfigOption {
+	return func(a *AppConfig) {
+		a.MaxBytes = maxBytes
+	}
+}
+
+// WithAppConfigTimeout returns an option that can set Timeout on a AppConfig
+func WithAppConfigTimeout(timeout time.Duration) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Timeout = timeout
+	}
+}
+
+// WithAppConfigHosts returns an option that can append Hostss to AppConfig.Hosts
+func WithAppConfigHosts(hosts string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Hosts = append(a.Hosts, hosts)
+	}
+}
+
+// SetAppConfigHosts returns an option that can set Hosts on a AppConfig
+func SetAppConfigHosts(hosts []string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Hosts = hosts
+	}
+}
+
+// WithAppConfigPorts returns an option that can append Portss to AppConfig.Ports
+func WithAppConfigPorts(ports int) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Ports = append(a.Ports, ports)
+	}
+}
+
+// SetAppConfigPorts returns an option that can set Ports on a AppConfig
+func SetAppConfigPorts(ports []int) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Ports = ports
+	}
+}
+
+// WithAppConfigLabels returns an option that can append Labelss to AppConfig.Labels
+func WithAppConfigLabels(key string, value string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Labels[key] = value
+	}
+}
+
+// SetAppConfigLabels returns an option that can set Labels on a AppConfig
+func SetAppConfigLabels(labels map[string]string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Labels = labels
+	}
+}
+
+// WithAppConfigLimits returns an option that can append Limitss to AppConfig.Limits
+func WithAppConfigLimits(key string, value int) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Limits[key] = value
+	}
+}
+
+// SetAppConfigLimits returns an option that can set Limits on a AppConfig
+func SetAppConfigLimits(limits map[string]int) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Limits = limits
+	}
+}
+
+// WithAppConfigDatabase returns an option that can set Database on a AppConfig
+func WithAppConfigDatabase(database DatabaseConfig) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Database = database
+	}
+}
+
+// WithAppConfigCache returns an option that can set Cache on a AppConfig
+func WithAppConfigCache(cache *CacheConfig) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Cache = cache
+	}
+}
+
+// WithAppConfigPassword returns an option that can set Password on a AppConfig
+//
+// Password is sensitive: DebugMap shows it as "(sensitive)".
+func WithAppConfigPassword(password string) AppConfigOption {
+	return func(a *AppConfig) {
+		a.Password = password
+	}
+}
+
+// AppConfigOptionsFromEnv returns options for the fields of AppConfig whose environment
+// variables are set, named prefix followed by the field's env tag or its name in
+// UPPER_SNAKE_CASE. Slices and maps are comma-separated, with key=value map items,
+// and nested structs use the field's name and "_" as an extended prefix. Unset
+// variables produce no option, so defaults are kept.
+func AppConfigOptionsFromEnv(prefix string) ([]AppConfigOption, error) {
+	opts := make([]AppConfigOption, 0)
+	if value, ok := os.LookupEnv(prefix + "NAME"); ok {
+		opts = append(opts, func(a *AppConfig) {
+			a.Name = value
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "LISTEN_PORT"); ok {
+		parsed, err := strconv.ParseInt(value, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"LISTEN_PORT", err)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Port = int(parsed)
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "DEBUG"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"DEBUG", err)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Debug = parsed
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "LEVEL"); ok {
+		parsed, err := strconv.ParseInt(value, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"LEVEL", err)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Level = Level(parsed)
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "RATIO"); ok {
+		parsed, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"RATIO", err)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Ratio = float32(parsed)
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "MAX_BYTES"); ok {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"MAX_BYTES", err)
+		}
+		fieldValue := parsed
+		opts = append(opts, func(a *AppConfig) {
+			a.MaxBytes = &fieldValue
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "TIMEOUT"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"TIMEOUT", err)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Timeout = parsed
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "HOSTS"); ok {
+		items := strings.Split(value, ",")
+		if value == "" {
+			items = nil
+		}
+		parsed := make([]string, 0, len(items))
+		for _, item := range items {
+			parsed = append(parsed, strings.TrimSpace(item))
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Hosts = parsed
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "PORTS"); ok {
+		items := strings.Split(value, ",")
+		if value == "" {
+			items = nil
+		}
+		parsed := make([]int, 0, len(items))
+		for _, item := range items {
+			parsedItem, err := strconv.ParseInt(strings.TrimSpace(item), 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", prefix+"PORTS", err)
+			}
+			parsed = append(parsed, int(parsedItem))
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Ports = parsed
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "LABELS"); ok {
+		items := strings.Split(value, ",")
+		if value == "" {
+			items = nil
+		}
+		parsed := make(map[string]string, len(items))
+		for _, item := range items {
+			itemKey, itemValue, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %q is not a key=value pair", prefix+"LABELS", item)
+			}
+			parsed[strings.TrimSpace(itemKey)] = strings.TrimSpace(itemValue)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Labels = parsed
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "LIMITS"); ok {
+		items := strings.Split(value, ",")
+		if value == "" {
+			items = nil
+		}
+		parsed := make(map[string]int, len(items))
+		for _, item := range items {
+			itemKey, itemValue, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %q is not a key=value pair", prefix+"LIMITS", item)
+			}
+			parsedValue, err := strconv.ParseInt(strings.TrimSpace(itemValue), 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", prefix+"LIMITS", err)
+			}
+			parsed[strings.TrimSpace(itemKey)] = int(parsedValue)
+		}
+		opts = append(opts, func(a *AppConfig) {
+			a.Limits = parsed
+		})
+	}
+	if slices.ContainsFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, prefix+"DB_")
+	}) {
+		databaseOpts, err := DatabaseConfigOptionsFromEnv(prefix + "DB_")
+		if err != nil {
+			return nil, err
+		}
+		if len(databaseOpts) > 0 {
+			opts = append(opts, func(a *AppConfig) {
+				for _, opt := range databaseOpts {
+					opt(&a.Database)
+				}
+			})
+		}
+	}
+	if slices.ContainsFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, prefix+"CACHE_")
+	}) {
+		cacheOpts, err := CacheConfigOptionsFromEnv(prefix + "CACHE_")
+		if err != nil {
+			return nil, err
+		}
+		if len(cacheOpts) > 0 {
+			opts = append(opts, func(a *AppConfig) {
+				if a.Cache == nil {
+					a.Cache = &CacheConfig{}
+				}
+				for _, opt := range cacheOpts {
+					opt(a.Cache)
+				}
+			})
+		}
+	}
+	return opts, nil
+}
+
+type DatabaseConfigOption func(d *DatabaseConfig)
+
+// NewDatabaseConfigWithOptions creates a new DatabaseConfig with the passed in options set
+func NewDatabaseConfigWithOptions(opts ...DatabaseConfigOption) *DatabaseConfig {
+	d := &DatabaseConfig{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// NewDatabaseConfigWithOptionsAndDefaults creates a new DatabaseConfig with the passed in options set starting from the defaults
+func NewDatabaseConfigWithOptionsAndDefaults(opts ...DatabaseConfigOption) *DatabaseConfig {
+	d := &DatabaseConfig{}
+	defaults.MustSet(d)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// ToOption returns a new DatabaseConfigOption that sets the values from the passed in DatabaseConfig
+func (d *DatabaseConfig) ToOption() DatabaseConfigOption {
+	return func(to *DatabaseConfig) {
+		to.URL = d.URL
+		to.MaxIdleTime = d.MaxIdleTime
+	}
+}
+
+// DebugMap returns a map form of DatabaseConfig for debugging
+func (d *DatabaseConfig) DebugMap() map[string]any {
+	debugMap, _ := d.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of DatabaseConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (d *DatabaseConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(d).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if d.URL == "" {
+		debugMap["URL"] = "(empty)"
+	} else {
+		debugMap["URL"] = d.URL
+	}
+	if dm, ok := any(&d.MaxIdleTime).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["MaxIdleTime"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&d.MaxIdleTime).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["MaxIdleTime"] = dm.DebugMap()
+	} else {
+		debugMap["MaxIdleTime"] = d.MaxIdleTime
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of DatabaseConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (d *DatabaseConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(d.DebugMap())
+}
+
+// DatabaseConfigWithOptions configures an existing DatabaseConfig with the passed in options set
+func DatabaseConfigWithOptions(d *DatabaseConfig, opts ...DatabaseConfigOption) *DatabaseConfig {
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithOptions configures the receiver DatabaseConfig with the passed in options set
+func (d *DatabaseConfig) WithOptions(opts ...DatabaseConfigOption) *DatabaseConfig {
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithDatabaseConfigURL returns an option that can set URL on a DatabaseConfig
+func WithDatabaseConfigURL(uRL string) DatabaseConfigOption {
+	return func(d *DatabaseConfig) {
+		d.URL = uRL
+	}
+}
+
+// WithDatabaseConfigMaxIdleTime returns an option that can set MaxIdleTime on a DatabaseConfig
+func WithDatabaseConfigMaxIdleTime(maxIdleTime time.Duration) DatabaseConfigOption {
+	return func(d *DatabaseConfig) {
+		d.MaxIdleTime = maxIdleTime
+	}
+}
+
+// DatabaseConfigOptionsFromEnv returns options for the fields of DatabaseConfig whose environment
+// variables are set, named prefix followed by the field's env tag or its name in
+// UPPER_SNAKE_CASE. Slices and maps are comma-separated, with key=value map items,
+// and nested structs use the field's name and "_" as an extended prefix. Unset
+// variables produce no option, so defaults are kept.
+func DatabaseConfigOptionsFromEnv(prefix string) ([]DatabaseConfigOption, error) {
+	opts := make([]DatabaseConfigOption, 0)
+	if value, ok := os.LookupEnv(prefix + "URL"); ok {
+		opts = append(opts, func(d *DatabaseConfig) {
+			d.URL = value
+		})
+	}
+	if value, ok := os.LookupEnv(prefix + "MAX_IDLE_TIME"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"MAX_IDLE_TIME", err)
+		}
+		opts = append(opts, func(d *DatabaseConfig) {
+			d.MaxIdleTime = parsed
+		})
+	}
+	return opts, nil
+}
+
+type CacheConfigOption func(c *CacheConfig)
+
+// NewCacheConfigWithOptions creates a new CacheConfig with the passed in options set
+func NewCacheConfigWithOptions(opts ...CacheConfigOption) *CacheConfig {
+	c := &CacheConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewCacheConfigWithOptionsAndDefaults creates a new CacheConfig with the passed in options set starting from the defaults
+func NewCacheConfigWithOptionsAndDefaults(opts ...CacheConfigOption) *CacheConfig {
+	c := &CacheConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new CacheConfigOption that sets the values from the passed in CacheConfig
+func (c *CacheConfig) ToOption() CacheConfigOption {
+	return func(to *CacheConfig) {
+		to.Size = c.Size
+	}
+}
+
+// DebugMap returns a map form of CacheConfig for debugging
+func (c *CacheConfig) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of CacheConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *CacheConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	debugMap["Size"] = c.Size
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of CacheConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *CacheConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// CacheConfigWithOptions configures an existing CacheConfig with the passed in options set
+func CacheConfigWithOptions(c *CacheConfig, opts ...CacheConfigOption) *CacheConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver CacheConfig with the passed in options set
+func (c *CacheConfig) WithOptions(opts ...CacheConfigOption) *CacheConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithCacheConfigSize returns an option that can set Size on a CacheConfig
+func WithCacheConfigSize(size int) CacheConfigOption {
+	return func(c *CacheConfig) {
+		c.Size = size
+	}
+}
+
+// CacheConfigOptionsFromEnv returns options for the fields of CacheConfig whose environment
+// variables are set, named prefix followed by the field's env tag or its name in
+// UPPER_SNAKE_CASE. Slices and maps are comma-separated, with key=value map items,
+// and nested structs use the field's name and "_" as an extended prefix. Unset
+// variables produce no option, so defaults are kept.
+func CacheConfigOptionsFromEnv(prefix string) ([]CacheConfigOption, error) {
+	opts := make([]CacheConfigOption, 0)
+	if value, ok := os.LookupEnv(prefix + "SIZE"); ok {
+		parsed, err := strconv.ParseInt(value, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", prefix+"SIZE", err)
+		}
+		opts = append(opts, func(c *CacheConfig) {
+			c.Size = int(parsed)
+		})
+	}
+	return opts, nil
+}
+
+type ChainConfigOption func(c *ChainConfig)
+
+// NewChainConfigWithOptions creates a new ChainConfig with the passed in options set
+func NewChainConfigWithOptions(opts ...ChainConfigOption) *ChainConfig {
+	c := &ChainConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewChainConfigWithOptionsAndDefaults creates a new ChainConfig with the passed in options set starting from the defaults
+func NewChainConfigWithOptionsAndDefaults(opts ...ChainConfigOption) *ChainConfig {
+	c := &ChainConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new ChainConfigOption that sets the values from the passed in ChainConfig
+func (c *ChainConfig) ToOption() ChainConfigOption {
+	return func(to *ChainConfig) {
+		to.Name = c.Name
+		to.Next = c.Next
+	}
+}
+
+// DebugMap returns a map form of ChainConfig for debugging
+func (c *ChainConfig) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of ChainConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *ChainConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.Name == "" {
+		debugMap["Name"] = "(empty)"
+	} else {
+		debugMap["Name"] = c.Name
+	}
+	if c.Next == nil {
+		debugMap["Next"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(c.Next).Pointer()]; ok {
+		debugMap["Next"] = "(cycle)"
+	} else if dm, ok := any(c.Next).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Next"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(c.Next).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Next"] = dm.DebugMap()
+	} else {
+		debugMap["Next"] = *c.Next
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of ChainConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *ChainConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// ChainConfigWithOptions configures an existing ChainConfig with the passed in options set
+func ChainConfigWithOptions(c *ChainConfig, opts ...ChainConfigOption) *ChainConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver ChainConfig with the passed in options set
+func (c *ChainConfig) WithOptions(opts ...ChainConfigOption) *ChainConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithChainConfigName returns an option that can set Name on a ChainConfig
+func WithChainConfigName(name string) ChainConfigOption {
+	return func(c *ChainConfig) {
+		c.Name = name
+	}
+}
+
+// WithChainConfigNext returns an option that can set Next on a ChainConfig
+func WithChainConfigNext(next *ChainConfig) ChainConfigOption {
+	return func(c *ChainConfig) {
+		c.Next = next
+	}
+}
+
+// ChainConfigOptionsFromEnv returns options for the fields of ChainConfig whose environment
+// variables are set, named prefix followed by the field's env tag or its name in
+// UPPER_SNAKE_CASE. Slices and maps are comma-separated, with key=value map items,
+// and nested structs use the field's name and "_" as an extended prefix. Unset
+// variables produce no option, so defaults are kept.
+func ChainConfigOptionsFromEnv(prefix string) ([]ChainConfigOption, error) {
+	opts := make([]ChainConfigOption, 0)
+	if value, ok := os.LookupEnv(prefix + "NAME"); ok {
+		opts = append(opts, func(c *ChainConfig) {
+			c.Name = value
+		})
+	}
+	if slices.ContainsFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, prefix+"NEXT_")
+	}) {
+		nextOpts, err := ChainConfigOptionsFromEnv(prefix + "NEXT_")
+		if err != nil {
+			return nil, err
+		}
+		if len(nextOpts) > 0 {
+			opts = append(opts, func(c *ChainConfig) {
+				if c.Next == nil {
+					c.Next = &ChainConfig{}
+				}
+				for _, opt := range nextOpts {
+					opt(c.Next)
+				}
+			})
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,43 @@
+package testdata
+
+import "time"
+
+// Level is a named type read from the environment like its underlying type
+type Level int8
+
+// AppConfig reads its fields from environment variables
+type AppConfig struct {
+	Name     string            `debugmap:"visible"`
+	Port     int               `debugmap:"visible" env:"LISTEN_PORT"`
+	Debug    bool              `debugmap:"visible"`
+	Level    Level             `debugmap:"visible"`
+	Ratio    float32           `debugmap:"visible"`
+	MaxBytes *uint64           `debugmap:"visible"`
+	Timeout  time.Duration     `debugmap:"visible"`
+	Hosts    []string          `debugmap:"visible"`
+	Ports    []int             `debugmap:"visible"`
+	Labels   map[string]string `debugmap:"visible"`
+	Limits   map[string]int    `debugmap:"visible"`
+	Database DatabaseConfig    `debugmap:"visible" env:"DB"`
+	Cache    *CacheConfig      `debugmap:"visible"`
+	Password string            `debugmap:"sensitive" env:"-"`
+	internal string
+}
+
+// DatabaseConfig is nested in AppConfig
+type DatabaseConfig struct {
+	URL         string        `debugmap:"visible"`
+	MaxIdleTime time.Duration `debugmap:"visible"`
+}
+
+// CacheConfig is nested in AppConfig behind a pointer
+type CacheConfig struct {
+	Size int `debugmap:"visible"`
+}
+
+// ChainConfig refers to itself, which is read from the environment only as
+// deep as variables are set
+type ChainConfig struct {
+	Name string       `debugmap:"visible"`
+	Next *ChainConfig `debugmap:"visible"`
+}
@@ -0,0 +1,8 @@
+package testdata
+
+// BadAliases has aliases that collide with fields and an unknown option.
+type BadAliases struct {
+	Name  string `debugmap:"visible" optgen:"alias=Title"`
+	Title string `debugmap:"visible"`
+	Label string `debugmap:"visible" optgen:"rename=Tag"`
+}
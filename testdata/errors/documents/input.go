@@ -0,0 +1,9 @@
+package testdata
+
+// BadDocument has fields that can't be read from config documents.
+type BadDocument struct {
+	Events  chan string    `debugmap:"hidden"`
+	Ports   map[int]string `debugmap:"visible" json:"ports"`
+	Skipped chan int       `debugmap:"hidden" json:"-"`
+	Matrix  [][]string     `debugmap:"visible" yaml:"matrix"`
+}
@@ -0,0 +1,9 @@
+package testdata
+
+// BadEnv has fields that can't be read from environment variables.
+type BadEnv struct {
+	Events  chan string      `debugmap:"hidden"`
+	Matrix  [][]string       `debugmap:"visible"`
+	Skipped chan int         `debugmap:"hidden" env:"-"`
+	Nested  map[string][]int `debugmap:"visible"`
+}
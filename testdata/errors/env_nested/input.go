@@ -0,0 +1,11 @@
+package testdata
+
+// OuterConfig nests a struct that isn't generated with the env emitter.
+type OuterConfig struct {
+	Inner InnerConfig `debugmap:"visible"`
+}
+
+// InnerConfig has no InnerConfigOptionsFromEnv.
+type InnerConfig struct {
+	Name string `debugmap:"visible"`
+}
@@ -0,0 +1,9 @@
+package testdata
+
+// BadFlags has flag tags that can't be generated.
+type BadFlags struct {
+	Labels  map[string]string `debugmap:"visible" flag:"labels"`
+	Name    string            `debugmap:"visible" flag:"name"`
+	Alias   string            `debugmap:"visible" flag:"name"`
+	Retries **int             `debugmap:"visible" flag:""`
+}
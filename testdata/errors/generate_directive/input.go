@@ -0,0 +1,8 @@
+package testdata
+
+// BadDirective has invalid //optgen:generate arguments.
+//
+//optgen:generate prefix=maybe colour=red
+type BadDirective struct {
+	Name string `debugmap:"visible"`
+}
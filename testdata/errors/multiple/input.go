@@ -0,0 +1,10 @@
+package multiple
+
+// Broken has several problems that are all reported in one run.
+type Broken struct {
+	Name     string
+	Password string            `debugmap:"visible"`
+	Mode     string            `debugmap:"shown"`
+	Digest   [32]byte          `debugmap:"visible"`
+	Labels   map[string]string `debugmap:"visible,sorted"`
+}
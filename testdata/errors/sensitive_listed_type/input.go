@@ -0,0 +1,10 @@
+package testdata
+
+import (
+	"crypto/tls"
+)
+
+// ListedType has a visible field whose type is listed in -sensitive-types.
+type ListedType struct {
+	Cert tls.Certificate `debugmap:"visible"`
+}
@@ -0,0 +1,8 @@
+package testdata
+
+// SensitiveName has visible fields whose names look sensitive.
+type SensitiveName struct {
+	KeyID      string `debugmap:"visible"`
+	SigningKey string `debugmap:"visible"`
+	Password   string `debugmap:"visible"`
+}
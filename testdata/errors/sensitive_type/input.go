@@ -0,0 +1,12 @@
+package testdata
+
+// Token is a secret; the Sensitive marker method tells optgen so.
+type Token string
+
+// Sensitive marks Token as holding a secret.
+func (*Token) Sensitive() {}
+
+// MarkerType has a visible field whose type implements the marker interface.
+type MarkerType struct {
+	Creds map[string]Token `debugmap:"visible-format"`
+}
@@ -0,0 +1,7 @@
+package testdata
+
+// Untagged has fields without debugmap tags.
+type Untagged struct {
+	Name     string
+	Password string
+}
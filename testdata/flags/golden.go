@@ -0,0 +1,298 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=3e75131e55a8c9df settings=7c4cae07d971e330 body=328f6ada52f07093
+package testdata
+
+import (
+	"flag"
+	"fmt"
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type ServerConfigOption func(s *ServerConfig)
+
+// NewServerConfigWithOptions creates a new ServerConfig with the passed in options set
+func NewServerConfigWithOptions(opts ...ServerConfigOption) *ServerConfig {
+	s := &ServerConfig{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewServerConfigWithOptionsAndDefaults creates a new ServerConfig with the passed in options set starting from the defaults
+func NewServerConfigWithOptionsAndDefaults(opts ...ServerConfigOption) *ServerConfig {
+	s := &ServerConfig{}
+	defaults.MustSet(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ToOption returns a new ServerConfigOption that sets the values from the passed in ServerConfig
+func (s *ServerConfig) ToOption() ServerConfigOption {
+	return func(to *ServerConfig) {
+		to.Host = s.Host
+		to.Port = s.Port
+		to.Verbose = s.Verbose
+		to.MaxBytes = s.MaxBytes
+		to.Ratio = s.Ratio
+		to.Timeout = s.Timeout
+		to.IdleTimeout = s.IdleTimeout
+		to.Origins = s.Origins
+		to.Password = s.Password
+		to.Internal = s.Internal
+	}
+}
+
+// DebugMap returns a map form of ServerConfig for debugging
+func (s *ServerConfig) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of ServerConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *ServerConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if s.Host == "" {
+		debugMap["Host"] = "(empty)"
+	} else {
+		debugMap["Host"] = s.Host
+	}
+	debugMap["Port"] = s.Port
+	debugMap["Verbose"] = s.Verbose
+	debugMap["MaxBytes"] = s.MaxBytes
+	debugMap["Ratio"] = s.Ratio
+	if dm, ok := any(&s.Timeout).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Timeout"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&s.Timeout).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["Timeout"] = dm.DebugMap()
+	} else {
+		debugMap["Timeout"] = s.Timeout
+	}
+	if s.IdleTimeout == nil {
+		debugMap["IdleTimeout"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(s.IdleTimeout).Pointer()]; ok {
+		debugMap["IdleTimeout"] = "(cycle)"
+	} else if dm, ok := any(s.IdleTimeout).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["IdleTimeout"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(s.IdleTimeout).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["IdleTimeout"] = dm.DebugMap()
+	} else {
+		debugMap["IdleTimeout"] = *s.IdleTimeout
+	}
+	if s.Origins == nil {
+		debugMap["Origins"] = "nil"
+	} else {
+		debugMap["Origins"] = fmt.Sprintf("(slice of size %d)", len(s.Origins))
+	}
+	if s.Password == "" {
+		debugMap["Password"] = "(empty)"
+	} else {
+		debugMap["Password"] = "(sensitive)"
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of ServerConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (s *ServerConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(s.DebugMap())
+}
+
+// ServerConfigWithOptions configures an existing ServerConfig with the passed in options set
+func ServerConfigWithOptions(s *ServerConfig, opts ...ServerConfigOption) *ServerConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithOptions configures the receiver ServerConfig with the passed in options set
+func (s *ServerConfig) WithOptions(opts ...ServerConfigOption) *ServerConfig {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithHost returns an option that can set Host on a ServerConfig
+func WithHost(host string) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Host = host
+	}
+}
+
+// WithPort returns an option that can set Port on a ServerConfig
+func WithPort(port int) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Port = port
+	}
+}
+
+// WithVerbose returns an option that can set Verbose on a ServerConfig
+func WithVerbose(verbose bool) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Verbose = verbose
+	}
+}
+
+// WithMaxBytes returns an option that can set MaxBytes on a ServerConfig
+func WithMaxBytes(maxBytes int64) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.MaxBytes = maxBytes
+	}
+}
+
+// WithRatio returns an option that can set Ratio on a ServerConfig
+func WithRatio(ratio float64) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Ratio = ratio
+	}
+}
+
+// WithTimeout returns an option that can set Timeout on a ServerConfig
+func WithTimeout(timeout time.Duration) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Timeout = timeout
+	}
+}
+
+// WithIdleTimeout returns an option that can set IdleTimeout on a ServerConfig
+func WithIdleTimeout(idleTimeout *time.Duration) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.IdleTimeout = idleTimeout
+	}
+}
+
+// WithOrigins returns an option that can append Originss to ServerConfig.Origins
+func WithOrigins(origins string) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Origins = append(s.Origins, origins)
+	}
+}
+
+// SetOrigins returns an option that can set Origins on a ServerConfig
+func SetOrigins(origins []string) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Origins = origins
+	}
+}
+
+// WithPassword returns an option that can set Password on a ServerConfig
+//
+// Password is sensitive: DebugMap shows it as "(sensitive)".
+func WithPassword(password string) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Password = password
+	}
+}
+
+// WithInternal returns an option that can set Internal on a ServerConfig
+//
+// DebugMap leaves Internal out.
+func WithInternal(internal string) ServerConfigOption {
+	return func(s *ServerConfig) {
+		s.Internal = internal
+	}
+}
+
+// RegisterServerConfigFlags defines a flag on fs for each field of ServerConfig with a flag tag, with
+// prefix prepended to the flag names. The returned function returns a ServerConfigOption
+// that sets only the fields whose flags were set, so it can be applied over defaults.
+func RegisterServerConfigFlags(fs *flag.FlagSet, prefix string) func() ServerConfigOption {
+	flagHost := fs.String(prefix+"host", "", "Host to listen on")
+	flagPort := fs.Int(prefix+"port", 0, "Port to listen on")
+	flagVerbose := fs.Bool(prefix+"v", false, "Log verbosely")
+	flagMaxBytes := fs.Int64(prefix+"max-bytes", 0, "Maximum request size, in bytes")
+	flagRatio := fs.Float64(prefix+"ratio", 0.0, "")
+	flagTimeout := fs.Duration(prefix+"timeout", 0, "Request timeout")
+	flagIdleTimeout := fs.Duration(prefix+"idle-timeout", 0, "Idle connection timeout")
+	flagOrigins := new([]string)
+	fs.Func(prefix+"origin", "Allowed origin, repeatable", func(value string) error {
+		*flagOrigins = append(*flagOrigins, value)
+		return nil
+	})
+	return func() ServerConfigOption {
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+		return func(s *ServerConfig) {
+			if set[prefix+"host"] {
+				s.Host = *flagHost
+			}
+			if set[prefix+"port"] {
+				s.Port = *flagPort
+			}
+			if set[prefix+"v"] {
+				s.Verbose = *flagVerbose
+			}
+			if set[prefix+"max-bytes"] {
+				s.MaxBytes = *flagMaxBytes
+			}
+			if set[prefix+"ratio"] {
+				s.Ratio = *flagRatio
+			}
+			if set[prefix+"timeout"] {
+				s.Timeout = *flagTimeout
+			}
+			if set[prefix+"idle-timeout"] {
+				value := *flagIdleTimeout
+				s.IdleTimeout = &value
+			}
+			if set[prefix+"origin"] {
+				s.Origins = *flagOrigins
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package testdata
+
+import "time"
+
+// ServerConfig binds its fields to command line flags
+type ServerConfig struct {
+	Host        string         `debugmap:"visible" flag:"host,Host to listen on"`
+	Port        int            `debugmap:"visible" flag:",Port to listen on"`
+	Verbose     bool           `debugmap:"visible" flag:"v,Log verbosely"`
+	MaxBytes    int64          `debugmap:"visible" flag:"max-bytes,Maximum request size, in bytes"`
+	Ratio       float64        `debugmap:"visible" flag:"ratio"`
+	Timeout     time.Duration  `debugmap:"visible" flag:",Request timeout"`
+	IdleTimeout *time.Duration `debugmap:"visible" flag:",Idle connection timeout"`
+	Origins     []string       `debugmap:"visible" flag:"origin,Allowed origin, repeatable"`
+	Password    string         `debugmap:"sensitive" flag:"-"`
+	Internal    string         `debugmap:"hidden"`
+}
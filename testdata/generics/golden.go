@@ -1,9 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=9354b6e50ec8519d settings=08d209b868cd1dfa body=ad6017c9143dbf04
 package testdata
 
 import (
 	"fmt"
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 )
 
 type GenericConfigOption func(g *GenericConfig)
@@ -42,8 +45,28 @@ func (g *GenericConfig) ToOption() GenericConfigOption {
 
 // DebugMap returns a map form of GenericConfig for debugging
 func (g *GenericConfig) DebugMap() map[string]any {
+	debugMap, _ := g.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of GenericConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (g *GenericConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(g).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if dm, ok := any(&g.StringContainer).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["StringContainer"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&g.StringContainer).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["StringContainer"] = dm.DebugMap()
@@ -51,6 +74,10 @@ func (g *GenericConfig) DebugMap() map[string]any {
 		debugMap["StringContainer"] = g.StringContainer
 	}
 	if dm, ok := any(&g.IntContainer).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["IntContainer"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&g.IntContainer).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["IntContainer"] = dm.DebugMap()
@@ -58,6 +85,10 @@ func (g *GenericConfig) DebugMap() map[string]any {
 		debugMap["IntContainer"] = g.IntContainer
 	}
 	if dm, ok := any(&g.StringIntPair).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["StringIntPair"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&g.StringIntPair).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["StringIntPair"] = dm.DebugMap()
@@ -76,6 +107,12 @@ func (g *GenericConfig) DebugMap() map[string]any {
 	}
 	if g.OptionalContainer == nil {
 		debugMap["OptionalContainer"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(g.OptionalContainer).Pointer()]; ok {
+		debugMap["OptionalContainer"] = "(cycle)"
+	} else if dm, ok := any(g.OptionalContainer).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["OptionalContainer"] = dm.debugMapWithState(depth+1, seen)
 	} else if dm, ok := any(g.OptionalContainer).(interface {
 		DebugMap() map[string]any
 	}); ok {
@@ -92,14 +129,27 @@ func (g *GenericConfig) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of GenericConfig for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (g *GenericConfig) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -129,6 +179,8 @@ func (g *GenericConfig) WithOptions(opts ...GenericConfigOption) *GenericConfig
 }
 
 // WithStringContainer returns an option that can set StringContainer on a GenericConfig
+//
+// Single type parameter.
 func WithStringContainer(stringContainer Container[string]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.StringContainer = stringContainer
@@ -143,6 +195,8 @@ func WithIntContainer(intContainer Container[int]) GenericConfigOption {
 }
 
 // WithStringIntPair returns an option that can set StringIntPair on a GenericConfig
+//
+// Multiple type parameters.
 func WithStringIntPair(stringIntPair Pair[string, int]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.StringIntPair = stringIntPair
@@ -150,6 +204,8 @@ func WithStringIntPair(stringIntPair Pair[string, int]) GenericConfigOption {
 }
 
 // WithContainers returns an option that can append Containerss to GenericConfig.Containers
+//
+// Slices of generic types.
 func WithContainers(containers Container[string]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.Containers = append(g.Containers, containers)
@@ -157,6 +213,8 @@ func WithContainers(containers Container[string]) GenericConfigOption {
 }
 
 // SetContainers returns an option that can set Containers on a GenericConfig
+//
+// Slices of generic types.
 func SetContainers(containers []Container[string]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.Containers = containers
@@ -178,6 +236,8 @@ func SetPairs(pairs []Pair[int, string]) GenericConfigOption {
 }
 
 // WithOptionalContainer returns an option that can set OptionalContainer on a GenericConfig
+//
+// Pointers to generic types.
 func WithOptionalContainer(optionalContainer *Container[bool]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.OptionalContainer = optionalContainer
@@ -185,6 +245,8 @@ func WithOptionalContainer(optionalContainer *Container[bool]) GenericConfigOpti
 }
 
 // WithContainerMap returns an option that can append ContainerMaps to GenericConfig.ContainerMap
+//
+// Map with generic value type.
 func WithContainerMap(key string, value Container[int]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.ContainerMap[key] = value
@@ -192,6 +254,8 @@ func WithContainerMap(key string, value Container[int]) GenericConfigOption {
 }
 
 // SetContainerMap returns an option that can set ContainerMap on a GenericConfig
+//
+// Map with generic value type.
 func SetContainerMap(containerMap map[string]Container[int]) GenericConfigOption {
 	return func(g *GenericConfig) {
 		g.ContainerMap = containerMap
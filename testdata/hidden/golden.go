@@ -1,7 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=6f8d96f14e06575a settings=3cba543be945164b body=d5d30f484494c957
 package testdata
 
-import defaults "github.com/creasty/defaults"
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
 
 type HiddenFieldsOption func(h *HiddenFields)
 
@@ -35,6 +40,22 @@ func (h *HiddenFields) ToOption() HiddenFieldsOption {
 
 // DebugMap returns a map form of HiddenFields for debugging
 func (h *HiddenFields) DebugMap() map[string]any {
+	debugMap, _ := h.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of HiddenFields for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (h *HiddenFields) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(h).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if h.PublicName == "" {
 		debugMap["PublicName"] = "(empty)"
@@ -50,14 +71,27 @@ func (h *HiddenFields) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of HiddenFields for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (h *HiddenFields) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -94,6 +128,8 @@ func WithPublicName(publicName string) HiddenFieldsOption {
 }
 
 // WithHiddenField returns an option that can set HiddenField on a HiddenFields
+//
+// DebugMap leaves HiddenField out.
 func WithHiddenField(hiddenField string) HiddenFieldsOption {
 	return func(h *HiddenFields) {
 		h.HiddenField = hiddenField
@@ -1,7 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=3a0b68978a7bd41f settings=4d2dcb80eb64a77c body=2eb138df30d2d9d1
 package testdata
 
-import defaults "github.com/creasty/defaults"
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
 
 type NestedConfigOption func(n *NestedConfig)
 
@@ -34,6 +39,22 @@ func (n *NestedConfig) ToOption() NestedConfigOption {
 
 // DebugMap returns a map form of NestedConfig for debugging
 func (n *NestedConfig) DebugMap() map[string]any {
+	debugMap, _ := n.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of NestedConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (n *NestedConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(n).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if n.URI == "" {
 		debugMap["URI"] = "(empty)"
@@ -49,14 +70,27 @@ func (n *NestedConfig) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of NestedConfig for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (n *NestedConfig) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -86,6 +120,8 @@ func (n *NestedConfig) WithOptions(opts ...NestedConfigOption) *NestedConfig {
 }
 
 // WithURI returns an option that can set URI on a NestedConfig
+//
+// URI is sensitive: DebugMap shows it as "(sensitive)".
 func WithURI(uRI string) NestedConfigOption {
 	return func(n *NestedConfig) {
 		n.URI = uRI
@@ -131,6 +167,22 @@ func (o *OuterConfig) ToOption() OuterConfigOption {
 
 // DebugMap returns a map form of OuterConfig for debugging
 func (o *OuterConfig) DebugMap() map[string]any {
+	debugMap, _ := o.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of OuterConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (o *OuterConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(o).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if o.Name == "" {
 		debugMap["Name"] = "(empty)"
@@ -138,6 +190,10 @@ func (o *OuterConfig) DebugMap() map[string]any {
 		debugMap["Name"] = o.Name
 	}
 	if dm, ok := any(&o.Nested).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["Nested"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&o.Nested).(interface {
 		DebugMap() map[string]any
 	}); ok {
 		debugMap["Nested"] = dm.DebugMap()
@@ -146,6 +202,12 @@ func (o *OuterConfig) DebugMap() map[string]any {
 	}
 	if o.NestedPtr == nil {
 		debugMap["NestedPtr"] = "nil"
+	} else if _, ok := seen[reflect.ValueOf(o.NestedPtr).Pointer()]; ok {
+		debugMap["NestedPtr"] = "(cycle)"
+	} else if dm, ok := any(o.NestedPtr).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["NestedPtr"] = dm.debugMapWithState(depth+1, seen)
 	} else if dm, ok := any(o.NestedPtr).(interface {
 		DebugMap() map[string]any
 	}); ok {
@@ -157,14 +219,27 @@ func (o *OuterConfig) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of OuterConfig for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (o *OuterConfig) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -213,3 +288,290 @@ func WithNestedPtr(nestedPtr *NestedConfig) OuterConfigOption {
 		o.NestedPtr = nestedPtr
 	}
 }
+
+type CollectionConfigOption func(c *CollectionConfig)
+
+// NewCollectionConfigWithOptions creates a new CollectionConfig with the passed in options set
+func NewCollectionConfigWithOptions(opts ...CollectionConfigOption) *CollectionConfig {
+	c := &CollectionConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewCollectionConfigWithOptionsAndDefaults creates a new CollectionConfig with the passed in options set starting from the defaults
+func NewCollectionConfigWithOptionsAndDefaults(opts ...CollectionConfigOption) *CollectionConfig {
+	c := &CollectionConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new CollectionConfigOption that sets the values from the passed in CollectionConfig
+func (c *CollectionConfig) ToOption() CollectionConfigOption {
+	return func(to *CollectionConfig) {
+		to.Items = c.Items
+		to.Backends = c.Backends
+		to.Labels = c.Labels
+		to.Groups = c.Groups
+		to.Pools = c.Pools
+	}
+}
+
+// DebugMap returns a map form of CollectionConfig for debugging
+func (c *CollectionConfig) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of CollectionConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *CollectionConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.Items == nil {
+		debugMap["Items"] = "nil"
+	} else {
+		debugItems := make([]any, 0, len(c.Items))
+		for _, v := range c.Items {
+			if dm, ok := any(&v).(interface {
+				debugMapWithState(int, map[uintptr]struct{}) any
+			}); ok {
+				debugItems = append(debugItems, dm.debugMapWithState(depth+1, seen))
+			} else if dm, ok := any(&v).(interface {
+				DebugMap() map[string]any
+			}); ok {
+				debugItems = append(debugItems, dm.DebugMap())
+			} else {
+				debugItems = append(debugItems, v)
+			}
+		}
+		debugMap["Items"] = debugItems
+	}
+	if c.Backends == nil {
+		debugMap["Backends"] = "nil"
+	} else {
+		debugBackends := make(map[string]any, len(c.Backends))
+		for k, v := range c.Backends {
+			if v == nil {
+				debugBackends[k] = "nil"
+			} else if _, ok := seen[reflect.ValueOf(v).Pointer()]; ok {
+				debugBackends[k] = "(cycle)"
+			} else if dm, ok := any(v).(interface {
+				debugMapWithState(int, map[uintptr]struct{}) any
+			}); ok {
+				debugBackends[k] = dm.debugMapWithState(depth+1, seen)
+			} else if dm, ok := any(v).(interface {
+				DebugMap() map[string]any
+			}); ok {
+				debugBackends[k] = dm.DebugMap()
+			} else {
+				debugBackends[k] = *v
+			}
+		}
+		debugMap["Backends"] = debugBackends
+	}
+	if c.Labels == nil {
+		debugMap["Labels"] = "nil"
+	} else {
+		debugLabels := make(map[string]any, len(c.Labels))
+		for k, v := range c.Labels {
+			if v == "" {
+				debugLabels[k] = "(empty)"
+			} else {
+				debugLabels[k] = v
+			}
+		}
+		debugMap["Labels"] = debugLabels
+	}
+	if c.Groups == nil {
+		debugMap["Groups"] = "nil"
+	} else {
+		debugGroups := make([]any, 0, len(c.Groups))
+		for _, v := range c.Groups {
+			if v == nil {
+				debugGroups = append(debugGroups, "nil")
+			} else {
+				debugV2 := make([]any, 0, len(v))
+				for _, v2 := range v {
+					if dm, ok := any(&v2).(interface {
+						debugMapWithState(int, map[uintptr]struct{}) any
+					}); ok {
+						debugV2 = append(debugV2, dm.debugMapWithState(depth+1, seen))
+					} else if dm, ok := any(&v2).(interface {
+						DebugMap() map[string]any
+					}); ok {
+						debugV2 = append(debugV2, dm.DebugMap())
+					} else {
+						debugV2 = append(debugV2, v2)
+					}
+				}
+				debugGroups = append(debugGroups, debugV2)
+			}
+		}
+		debugMap["Groups"] = debugGroups
+	}
+	if c.Pools == nil {
+		debugMap["Pools"] = "nil"
+	} else {
+		debugPools := make(map[string]any, len(c.Pools))
+		for k, v := range c.Pools {
+			if v == nil {
+				debugPools[k] = "nil"
+			} else {
+				debugV2 := make([]any, 0, len(v))
+				for _, v2 := range v {
+					if v2 == nil {
+						debugV2 = append(debugV2, "nil")
+					} else if _, ok := seen[reflect.ValueOf(v2).Pointer()]; ok {
+						debugV2 = append(debugV2, "(cycle)")
+					} else if dm, ok := any(v2).(interface {
+						debugMapWithState(int, map[uintptr]struct{}) any
+					}); ok {
+						debugV2 = append(debugV2, dm.debugMapWithState(depth+1, seen))
+					} else if dm, ok := any(v2).(interface {
+						DebugMap() map[string]any
+					}); ok {
+						debugV2 = append(debugV2, dm.DebugMap())
+					} else {
+						debugV2 = append(debugV2, *v2)
+					}
+				}
+				debugPools[k] = debugV2
+			}
+		}
+		debugMap["Pools"] = debugPools
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of CollectionConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *CollectionConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// CollectionConfigWithOptions configures an existing CollectionConfig with the passed in options set
+func CollectionConfigWithOptions(c *CollectionConfig, opts ...CollectionConfigOption) *CollectionConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver CollectionConfig with the passed in options set
+func (c *CollectionConfig) WithOptions(opts ...CollectionConfigOption) *CollectionConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithItems returns an option that can append Itemss to CollectionConfig.Items
+func WithItems(items NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Items = append(c.Items, items)
+	}
+}
+
+// SetItems returns an option that can set Items on a CollectionConfig
+func SetItems(items []NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Items = items
+	}
+}
+
+// WithBackends returns an option that can append Backendss to CollectionConfig.Backends
+func WithBackends(key string, value *NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Backends[key] = value
+	}
+}
+
+// SetBackends returns an option that can set Backends on a CollectionConfig
+func SetBackends(backends map[string]*NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Backends = backends
+	}
+}
+
+// WithLabels returns an option that can append Labelss to CollectionConfig.Labels
+func WithLabels(key string, value string) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Labels[key] = value
+	}
+}
+
+// SetLabels returns an option that can set Labels on a CollectionConfig
+func SetLabels(labels map[string]string) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Labels = labels
+	}
+}
+
+// WithGroups returns an option that can append Groupss to CollectionConfig.Groups
+func WithGroups(groups []NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Groups = append(c.Groups, groups)
+	}
+}
+
+// SetGroups returns an option that can set Groups on a CollectionConfig
+func SetGroups(groups [][]NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Groups = groups
+	}
+}
+
+// WithPools returns an option that can append Poolss to CollectionConfig.Pools
+func WithPools(key string, value []*NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Pools[key] = value
+	}
+}
+
+// SetPools returns an option that can set Pools on a CollectionConfig
+func SetPools(pools map[string][]*NestedConfig) CollectionConfigOption {
+	return func(c *CollectionConfig) {
+		c.Pools = pools
+	}
+}
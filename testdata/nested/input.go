@@ -12,3 +12,13 @@ type OuterConfig struct {
 	Nested    NestedConfig  `debugmap:"visible"`
 	NestedPtr *NestedConfig `debugmap:"visible"`
 }
+
+// CollectionConfig holds NestedConfig values inside slices and maps, and
+// inside slices of slices and maps of slices.
+type CollectionConfig struct {
+	Items    []NestedConfig             `debugmap:"visible-format"`
+	Backends map[string]*NestedConfig   `debugmap:"visible-format"`
+	Labels   map[string]string          `debugmap:"visible-format"`
+	Groups   [][]NestedConfig           `debugmap:"visible-format"`
+	Pools    map[string][]*NestedConfig `debugmap:"visible-format"`
+}
@@ -0,0 +1,256 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=585f2094ecce92cc settings=da0c2458b8d1ab81 body=7e916a21301f7dc1
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	redact "github.com/ecordell/optgen/redact"
+	"reflect"
+	"strconv"
+)
+
+type DocumentedConfigOption func(d *DocumentedConfig)
+
+// NewDocumentedConfigWithOptions creates a new DocumentedConfig with the passed in options set
+func NewDocumentedConfigWithOptions(opts ...DocumentedConfigOption) *DocumentedConfig {
+	d := &DocumentedConfig{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// NewDocumentedConfigWithOptionsAndDefaults creates a new DocumentedConfig with the passed in options set starting from the defaults
+func NewDocumentedConfigWithOptionsAndDefaults(opts ...DocumentedConfigOption) *DocumentedConfig {
+	d := &DocumentedConfig{}
+	defaults.MustSet(d)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// ToOption returns a new DocumentedConfigOption that sets the values from the passed in DocumentedConfig
+func (d *DocumentedConfig) ToOption() DocumentedConfigOption {
+	return func(to *DocumentedConfig) {
+		to.Addr = d.Addr
+		to.Port = d.Port
+		to.Token = d.Token
+		to.Peers = d.Peers
+		to.Labels = d.Labels
+		to.DSN = d.DSN
+		to.Internal = d.Internal
+		to.Plain = d.Plain
+	}
+}
+
+// DebugMap returns a map form of DocumentedConfig for debugging
+func (d *DocumentedConfig) DebugMap() map[string]any {
+	debugMap, _ := d.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of DocumentedConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (d *DocumentedConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(d).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if d.Addr == "" {
+		debugMap["Addr"] = "(empty)"
+	} else {
+		debugMap["Addr"] = d.Addr
+	}
+	debugMap["Port"] = d.Port
+	if d.Token == "" {
+		debugMap["Token"] = "(empty)"
+	} else {
+		debugMap["Token"] = "(sensitive)"
+	}
+	if d.Peers == nil {
+		debugMap["Peers"] = "nil"
+	} else {
+		debugPeers := make([]any, 0, len(d.Peers))
+		for _, v := range d.Peers {
+			if v == "" {
+				debugPeers = append(debugPeers, "(empty)")
+			} else {
+				debugPeers = append(debugPeers, v)
+			}
+		}
+		debugMap["Peers"] = debugPeers
+	}
+	if d.Labels == nil {
+		debugMap["Labels"] = "nil"
+	} else {
+		debugLabels := make(map[string]any, len(d.Labels))
+		for k, v := range d.Labels {
+			if v == "" {
+				debugLabels[k] = "(empty)"
+			} else {
+				debugLabels[k] = v
+			}
+		}
+		debugMap["Labels"] = debugLabels
+	}
+	if d.DSN == "" {
+		debugMap["DSN"] = "(empty)"
+	} else {
+		debugMap["DSN"] = redact.ConnectionString(d.DSN)
+	}
+	debugMap["Plain"] = d.Plain
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of DocumentedConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (d *DocumentedConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(d.DebugMap())
+}
+
+// DocumentedConfigWithOptions configures an existing DocumentedConfig with the passed in options set
+func DocumentedConfigWithOptions(d *DocumentedConfig, opts ...DocumentedConfigOption) *DocumentedConfig {
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithOptions configures the receiver DocumentedConfig with the passed in options set
+func (d *DocumentedConfig) WithOptions(opts ...DocumentedConfigOption) *DocumentedConfig {
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithAddr returns an option that can set Addr on a DocumentedConfig
+//
+// Addr is the address to listen on, e.g. ":8080".
+//
+// It may also be a Unix socket path.
+//
+// Addr defaults to :8080 with NewDocumentedConfigWithOptionsAndDefaults.
+func WithAddr(addr string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Addr = addr
+	}
+}
+
+// WithPort returns an option that can set Port on a DocumentedConfig
+//
+// Port is the port to listen on.
+//
+// Deprecated: set the port in Addr instead.
+func WithPort(port int) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Port = port
+	}
+}
+
+// WithToken returns an option that can set Token on a DocumentedConfig
+//
+// Token authenticates requests.
+//
+// Token is sensitive: DebugMap shows it as "(sensitive)".
+func WithToken(token string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Token = token
+	}
+}
+
+// WithPeers returns an option that can append Peerss to DocumentedConfig.Peers
+//
+// Peers are the addresses of the other replicas.
+func WithPeers(peers string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Peers = append(d.Peers, peers)
+	}
+}
+
+// SetPeers returns an option that can set Peers on a DocumentedConfig
+//
+// Peers are the addresses of the other replicas.
+func SetPeers(peers []string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Peers = peers
+	}
+}
+
+// WithLabels returns an option that can append Labelss to DocumentedConfig.Labels
+//
+// Labels are attached to every metric.
+func WithLabels(key string, value string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Labels[key] = value
+	}
+}
+
+// SetLabels returns an option that can set Labels on a DocumentedConfig
+//
+// Labels are attached to every metric.
+func SetLabels(labels map[string]string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Labels = labels
+	}
+}
+
+// WithDSN returns an option that can set DSN on a DocumentedConfig
+//
+// DebugMap shows DSN with its secrets replaced by "xxxxx".
+func WithDSN(dSN string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.DSN = dSN
+	}
+}
+
+// WithInternal returns an option that can set Internal on a DocumentedConfig
+//
+// DebugMap leaves Internal out.
+func WithInternal(internal string) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Internal = internal
+	}
+}
+
+// WithPlain returns an option that can set Plain on a DocumentedConfig
+func WithPlain(plain bool) DocumentedConfigOption {
+	return func(d *DocumentedConfig) {
+		d.Plain = plain
+	}
+}
@@ -0,0 +1,27 @@
+package testdata
+
+// DocumentedConfig has fields whose doc comments are carried into the
+// generated options.
+type DocumentedConfig struct {
+	// Addr is the address to listen on, e.g. ":8080".
+	//
+	// It may also be a Unix socket path.
+	Addr string `debugmap:"visible" default:":8080"`
+
+	// Port is the port to listen on.
+	//
+	// Deprecated: set the port in Addr instead.
+	Port int `debugmap:"visible"`
+
+	Token string `debugmap:"sensitive"` // Token authenticates requests
+
+	// Peers are the addresses of the other replicas.
+	Peers []string `debugmap:"visible-format"`
+
+	// Labels are attached to every metric.
+	Labels map[string]string `debugmap:"visible-format"`
+
+	DSN      string `debugmap:"url-redacted"`
+	Internal string `debugmap:"hidden"`
+	Plain    bool   `debugmap:"visible"`
+}
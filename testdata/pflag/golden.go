@@ -0,0 +1,122 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=f30b7d05068bba16 settings=e0e86cfb7ca281c1 body=16cc88b41f2ad3a5
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	pflag "github.com/spf13/pflag"
+	"time"
+)
+
+type ClientConfigOption func(c *ClientConfig)
+
+// NewClientConfigWithOptions creates a new ClientConfig with the passed in options set
+func NewClientConfigWithOptions(opts ...ClientConfigOption) *ClientConfig {
+	c := &ClientConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClientConfigWithOptionsAndDefaults creates a new ClientConfig with the passed in options set starting from the defaults
+func NewClientConfigWithOptionsAndDefaults(opts ...ClientConfigOption) *ClientConfig {
+	c := &ClientConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new ClientConfigOption that sets the values from the passed in ClientConfig
+func (c *ClientConfig) ToOption() ClientConfigOption {
+	return func(to *ClientConfig) {
+		to.Endpoint = c.Endpoint
+		to.Retries = c.Retries
+		to.Timeout = c.Timeout
+		to.Headers = c.Headers
+	}
+}
+
+// ClientConfigWithOptions configures an existing ClientConfig with the passed in options set
+func ClientConfigWithOptions(c *ClientConfig, opts ...ClientConfigOption) *ClientConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver ClientConfig with the passed in options set
+func (c *ClientConfig) WithOptions(opts ...ClientConfigOption) *ClientConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithEndpoint returns an option that can set Endpoint on a ClientConfig
+func WithEndpoint(endpoint string) ClientConfigOption {
+	return func(c *ClientConfig) {
+		c.Endpoint = endpoint
+	}
+}
+
+// WithRetries returns an option that can set Retries on a ClientConfig
+func WithRetries(retries *int) ClientConfigOption {
+	return func(c *ClientConfig) {
+		c.Retries = retries
+	}
+}
+
+// WithTimeout returns an option that can set Timeout on a ClientConfig
+func WithTimeout(timeout time.Duration) ClientConfigOption {
+	return func(c *ClientConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithHeaders returns an option that can append Headerss to ClientConfig.Headers
+func WithHeaders(headers string) ClientConfigOption {
+	return func(c *ClientConfig) {
+		c.Headers = append(c.Headers, headers)
+	}
+}
+
+// SetHeaders returns an option that can set Headers on a ClientConfig
+func SetHeaders(headers []string) ClientConfigOption {
+	return func(c *ClientConfig) {
+		c.Headers = headers
+	}
+}
+
+// RegisterClientConfigFlags defines a flag on fs for each field of ClientConfig with a flag tag, with
+// prefix prepended to the flag names. The returned function returns a ClientConfigOption
+// that sets only the fields whose flags were set, so it can be applied over defaults.
+func RegisterClientConfigFlags(fs *pflag.FlagSet, prefix string) func() ClientConfigOption {
+	flagEndpoint := fs.String(prefix+"endpoint", "", "Server to connect to")
+	flagRetries := fs.Int(prefix+"retries", 0, "Number of retries")
+	flagTimeout := fs.Duration(prefix+"timeout", 0, "Request timeout")
+	flagHeaders := fs.StringSlice(prefix+"header", nil, "Headers to send")
+	return func() ClientConfigOption {
+		set := make(map[string]bool)
+		fs.Visit(func(f *pflag.Flag) {
+			set[f.Name] = true
+		})
+		return func(c *ClientConfig) {
+			if set[prefix+"endpoint"] {
+				c.Endpoint = *flagEndpoint
+			}
+			if set[prefix+"retries"] {
+				value := *flagRetries
+				c.Retries = &value
+			}
+			if set[prefix+"timeout"] {
+				c.Timeout = *flagTimeout
+			}
+			if set[prefix+"header"] {
+				c.Headers = *flagHeaders
+			}
+		}
+	}
+}
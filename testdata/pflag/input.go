@@ -0,0 +1,11 @@
+package testdata
+
+import "time"
+
+// ClientConfig binds its fields to pflag flags
+type ClientConfig struct {
+	Endpoint string        `flag:"endpoint,Server to connect to"`
+	Retries  *int          `flag:",Number of retries"`
+	Timeout  time.Duration `flag:",Request timeout"`
+	Headers  []string      `flag:"header,Headers to send"`
+}
@@ -0,0 +1,47 @@
+package testdata
+
+import "time"
+
+// Level is a logging verbosity
+type Level int8
+
+// ServerConfig configures the API server.
+//
+//optgen:generate
+type ServerConfig struct {
+	// Name identifies the server in logs.
+	Name string `debugmap:"visible" json:"name" validate:"required,min=1,max=64"`
+
+	Port    uint16        `debugmap:"visible" json:"port" yaml:"listen_port" default:"8080"` // Port to listen on
+	Debug   bool          `debugmap:"visible" json:"debug,omitempty" default:"false"`
+	Level   Level         `debugmap:"visible" json:"level" validate:"oneof=0 1 2"`
+	Ratio   float64       `debugmap:"visible" json:"ratio" validate:"gt=0,lte=1"`
+	Timeout time.Duration `debugmap:"visible" json:"timeout" default:"30s"`
+	Mode    string        `debugmap:"visible" json:"mode" validate:"oneof=strict lenient"`
+	Admin   string        `debugmap:"visible" json:"admin" validate:"email"`
+
+	// Hosts are the names the server answers to.
+	Hosts  []string          `debugmap:"visible-format" json:"hosts" validate:"min=1" default:"[\"localhost\"]"`
+	Labels map[string]string `debugmap:"visible-format" json:"labels"`
+
+	TLS     *TLSConfig   `debugmap:"visible" json:"tls"`
+	Backups []BackupRule `debugmap:"visible" json:"backups"`
+
+	Password string `debugmap:"sensitive" json:"password"`
+	Internal string `debugmap:"hidden" json:"-"`
+	Extra    any    `debugmap:"visible" json:"extra"`
+	internal string
+}
+
+// TLSConfig holds the certificate of a server.
+type TLSConfig struct {
+	CertFile string `debugmap:"visible" json:"cert_file" validate:"required"`
+	KeyFile  string `debugmap:"sensitive" json:"key_file"`
+}
+
+// BackupRule is how often to back up, and how many backups to keep.
+type BackupRule struct {
+	Every time.Duration `debugmap:"visible" json:"every"`
+	Keep  int           `debugmap:"visible" json:"keep" validate:"gte=1"`
+	Next  *BackupRule   `debugmap:"visible" json:"next"`
+}
@@ -1,7 +1,12 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=f4128dd92f6ce2c7 settings=456916949c824744 body=8b1a804e87f3d6fc
 package testdata
 
-import defaults "github.com/creasty/defaults"
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
 
 type CredentialsOption func(c *Credentials)
 
@@ -36,6 +41,22 @@ func (c *Credentials) ToOption() CredentialsOption {
 
 // DebugMap returns a map form of Credentials for debugging
 func (c *Credentials) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of Credentials for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *Credentials) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if c.Username == "" {
 		debugMap["Username"] = "(empty)"
@@ -61,14 +82,27 @@ func (c *Credentials) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of Credentials for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (c *Credentials) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -105,6 +139,8 @@ func WithUsername(username string) CredentialsOption {
 }
 
 // WithPassword returns an option that can set Password on a Credentials
+//
+// Password is sensitive: DebugMap shows it as "(sensitive)".
 func WithPassword(password string) CredentialsOption {
 	return func(c *Credentials) {
 		c.Password = password
@@ -112,6 +148,8 @@ func WithPassword(password string) CredentialsOption {
 }
 
 // WithAPIKey returns an option that can set APIKey on a Credentials
+//
+// APIKey is sensitive: DebugMap shows it as "(sensitive)".
 func WithAPIKey(aPIKey string) CredentialsOption {
 	return func(c *Credentials) {
 		c.APIKey = aPIKey
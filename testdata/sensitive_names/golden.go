@@ -0,0 +1,165 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=224d1b982b66a5ff settings=986b4e1f3d1e16f8 body=24e28adb02e5fd66
+package testdata
+
+import (
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type SensitiveNamesOption func(s *SensitiveNames)
+
+// NewSensitiveNamesWithOptions creates a new SensitiveNames with the passed in options set
+func NewSensitiveNamesWithOptions(opts ...SensitiveNamesOption) *SensitiveNames {
+	s := &SensitiveNames{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewSensitiveNamesWithOptionsAndDefaults creates a new SensitiveNames with the passed in options set starting from the defaults
+func NewSensitiveNamesWithOptionsAndDefaults(opts ...SensitiveNamesOption) *SensitiveNames {
+	s := &SensitiveNames{}
+	defaults.MustSet(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ToOption returns a new SensitiveNamesOption that sets the values from the passed in SensitiveNames
+func (s *SensitiveNames) ToOption() SensitiveNamesOption {
+	return func(to *SensitiveNames) {
+		to.TokenTTL = s.TokenTTL
+		to.MaxTokens = s.MaxTokens
+		to.KeyID = s.KeyID
+		to.Password = s.Password
+	}
+}
+
+// DebugMap returns a map form of SensitiveNames for debugging
+func (s *SensitiveNames) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of SensitiveNames for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *SensitiveNames) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if dm, ok := any(&s.TokenTTL).(interface {
+		debugMapWithState(int, map[uintptr]struct{}) any
+	}); ok {
+		debugMap["TokenTTL"] = dm.debugMapWithState(depth+1, seen)
+	} else if dm, ok := any(&s.TokenTTL).(interface {
+		DebugMap() map[string]any
+	}); ok {
+		debugMap["TokenTTL"] = dm.DebugMap()
+	} else {
+		debugMap["TokenTTL"] = s.TokenTTL
+	}
+	debugMap["MaxTokens"] = s.MaxTokens
+	if s.KeyID == "" {
+		debugMap["KeyID"] = "(empty)"
+	} else {
+		debugMap["KeyID"] = s.KeyID
+	}
+	if s.Password == "" {
+		debugMap["Password"] = "(empty)"
+	} else {
+		debugMap["Password"] = "(sensitive)"
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of SensitiveNames for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (s *SensitiveNames) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(s.DebugMap())
+}
+
+// SensitiveNamesWithOptions configures an existing SensitiveNames with the passed in options set
+func SensitiveNamesWithOptions(s *SensitiveNames, opts ...SensitiveNamesOption) *SensitiveNames {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithOptions configures the receiver SensitiveNames with the passed in options set
+func (s *SensitiveNames) WithOptions(opts ...SensitiveNamesOption) *SensitiveNames {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithTokenTTL returns an option that can set TokenTTL on a SensitiveNames
+func WithTokenTTL(tokenTTL time.Duration) SensitiveNamesOption {
+	return func(s *SensitiveNames) {
+		s.TokenTTL = tokenTTL
+	}
+}
+
+// WithMaxTokens returns an option that can set MaxTokens on a SensitiveNames
+func WithMaxTokens(maxTokens int) SensitiveNamesOption {
+	return func(s *SensitiveNames) {
+		s.MaxTokens = maxTokens
+	}
+}
+
+// WithKeyID returns an option that can set KeyID on a SensitiveNames
+func WithKeyID(keyID string) SensitiveNamesOption {
+	return func(s *SensitiveNames) {
+		s.KeyID = keyID
+	}
+}
+
+// WithPassword returns an option that can set Password on a SensitiveNames
+//
+// Password is sensitive: DebugMap shows it as "(sensitive)".
+func WithPassword(password string) SensitiveNamesOption {
+	return func(s *SensitiveNames) {
+		s.Password = password
+	}
+}
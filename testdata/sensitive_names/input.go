@@ -0,0 +1,13 @@
+package testdata
+
+import (
+	"time"
+)
+
+// SensitiveNames tests fields whose names look sensitive but aren't
+type SensitiveNames struct {
+	TokenTTL  time.Duration `debugmap:"visible,allow-sensitive-name"`
+	MaxTokens int           `debugmap:"visible,allow-sensitive-name"`
+	KeyID     string        `debugmap:"visible"`
+	Password  string        `debugmap:"sensitive"`
+}
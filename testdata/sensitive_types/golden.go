@@ -0,0 +1,168 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=25dfee14a5718e05 settings=b21f664894fe190f body=7e32b2750c9a9740
+package testdata
+
+import (
+	"crypto/tls"
+	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
+)
+
+type SensitiveTypesOption func(s *SensitiveTypes)
+
+// NewSensitiveTypesWithOptions creates a new SensitiveTypes with the passed in options set
+func NewSensitiveTypesWithOptions(opts ...SensitiveTypesOption) *SensitiveTypes {
+	s := &SensitiveTypes{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewSensitiveTypesWithOptionsAndDefaults creates a new SensitiveTypes with the passed in options set starting from the defaults
+func NewSensitiveTypesWithOptionsAndDefaults(opts ...SensitiveTypesOption) *SensitiveTypes {
+	s := &SensitiveTypes{}
+	defaults.MustSet(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ToOption returns a new SensitiveTypesOption that sets the values from the passed in SensitiveTypes
+func (s *SensitiveTypes) ToOption() SensitiveTypesOption {
+	return func(to *SensitiveTypes) {
+		to.Creds = s.Creds
+		to.Tokens = s.Tokens
+		to.Cert = s.Cert
+		to.Issuer = s.Issuer
+	}
+}
+
+// DebugMap returns a map form of SensitiveTypes for debugging
+func (s *SensitiveTypes) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of SensitiveTypes for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *SensitiveTypes) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	debugMap["Creds"] = "(sensitive)"
+	debugMap["Tokens"] = "(sensitive)"
+	if s.Cert == nil {
+		debugMap["Cert"] = "nil"
+	} else {
+		debugMap["Cert"] = "(sensitive)"
+	}
+	if s.Issuer == "" {
+		debugMap["Issuer"] = "(empty)"
+	} else {
+		debugMap["Issuer"] = s.Issuer
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of SensitiveTypes for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (s *SensitiveTypes) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(s.DebugMap())
+}
+
+// SensitiveTypesWithOptions configures an existing SensitiveTypes with the passed in options set
+func SensitiveTypesWithOptions(s *SensitiveTypes, opts ...SensitiveTypesOption) *SensitiveTypes {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithOptions configures the receiver SensitiveTypes with the passed in options set
+func (s *SensitiveTypes) WithOptions(opts ...SensitiveTypesOption) *SensitiveTypes {
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithCreds returns an option that can set Creds on a SensitiveTypes
+//
+// Creds is sensitive: DebugMap shows it as "(sensitive)".
+func WithCreds(creds Token) SensitiveTypesOption {
+	return func(s *SensitiveTypes) {
+		s.Creds = creds
+	}
+}
+
+// WithTokens returns an option that can append Tokenss to SensitiveTypes.Tokens
+//
+// Tokens is sensitive: DebugMap shows it as "(sensitive)".
+func WithTokens(tokens Token) SensitiveTypesOption {
+	return func(s *SensitiveTypes) {
+		s.Tokens = append(s.Tokens, tokens)
+	}
+}
+
+// SetTokens returns an option that can set Tokens on a SensitiveTypes
+//
+// Tokens is sensitive: DebugMap shows it as "(sensitive)".
+func SetTokens(tokens []Token) SensitiveTypesOption {
+	return func(s *SensitiveTypes) {
+		s.Tokens = tokens
+	}
+}
+
+// WithCert returns an option that can set Cert on a SensitiveTypes
+//
+// Cert is sensitive: DebugMap shows it as "(sensitive)".
+func WithCert(cert *tls.Certificate) SensitiveTypesOption {
+	return func(s *SensitiveTypes) {
+		s.Cert = cert
+	}
+}
+
+// WithIssuer returns an option that can set Issuer on a SensitiveTypes
+func WithIssuer(issuer string) SensitiveTypesOption {
+	return func(s *SensitiveTypes) {
+		s.Issuer = issuer
+	}
+}
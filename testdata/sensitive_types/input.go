@@ -0,0 +1,19 @@
+package testdata
+
+import (
+	"crypto/tls"
+)
+
+// Token is a secret; the Sensitive marker method tells optgen so.
+type Token string
+
+// Sensitive marks Token as holding a secret.
+func (Token) Sensitive() {}
+
+// SensitiveTypes tests fields whose types are sensitive
+type SensitiveTypes struct {
+	Creds  Token            `debugmap:"sensitive"`
+	Tokens []Token          `debugmap:"sensitive"`
+	Cert   *tls.Certificate `debugmap:"sensitive"`
+	Issuer string           `debugmap:"visible"`
+}
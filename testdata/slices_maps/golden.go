@@ -1,9 +1,11 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=cbea91dffd0378e8 settings=94746aca1671a489 body=cef5a9c18f01578b
 package testdata
 
 import (
-	"fmt"
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 )
 
 type SlicesAndMapsOption func(s *SlicesAndMaps)
@@ -38,6 +40,22 @@ func (s *SlicesAndMaps) ToOption() SlicesAndMapsOption {
 
 // DebugMap returns a map form of SlicesAndMaps for debugging
 func (s *SlicesAndMaps) DebugMap() map[string]any {
+	debugMap, _ := s.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of SlicesAndMaps for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (s *SlicesAndMaps) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(s).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if s.Tags == nil {
 		debugMap["Tags"] = "nil"
@@ -55,7 +73,21 @@ func (s *SlicesAndMaps) DebugMap() map[string]any {
 	if s.Metadata == nil {
 		debugMap["Metadata"] = "nil"
 	} else {
-		debugMap["Metadata"] = fmt.Sprintf("%v", s.Metadata)
+		debugMetadata := make(map[string]any, len(s.Metadata))
+		for k, v := range s.Metadata {
+			if dm, ok := any(&v).(interface {
+				debugMapWithState(int, map[uintptr]struct{}) any
+			}); ok {
+				debugMetadata[k] = dm.debugMapWithState(depth+1, seen)
+			} else if dm, ok := any(&v).(interface {
+				DebugMap() map[string]any
+			}); ok {
+				debugMetadata[k] = dm.DebugMap()
+			} else {
+				debugMetadata[k] = v
+			}
+		}
+		debugMap["Metadata"] = debugMetadata
 	}
 	if s.Ports == nil {
 		debugMap["Ports"] = "nil"
@@ -70,14 +102,27 @@ func (s *SlicesAndMaps) DebugMap() map[string]any {
 }
 
 // FlatDebugMap returns a flattened map form of SlicesAndMaps for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (s *SlicesAndMaps) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}
@@ -0,0 +1,172 @@
+// Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=cd73ecfaa91cb093 settings=210bb1219f708832 body=40a9de1bff480721
+package testdata
+
+import (
+	"database/sql"
+	defaults "github.com/creasty/defaults"
+	redact "github.com/ecordell/optgen/redact"
+	"reflect"
+	"strconv"
+)
+
+type ConnectionConfigOption func(c *ConnectionConfig)
+
+// NewConnectionConfigWithOptions creates a new ConnectionConfig with the passed in options set
+func NewConnectionConfigWithOptions(opts ...ConnectionConfigOption) *ConnectionConfig {
+	c := &ConnectionConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewConnectionConfigWithOptionsAndDefaults creates a new ConnectionConfig with the passed in options set starting from the defaults
+func NewConnectionConfigWithOptionsAndDefaults(opts ...ConnectionConfigOption) *ConnectionConfig {
+	c := &ConnectionConfig{}
+	defaults.MustSet(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ToOption returns a new ConnectionConfigOption that sets the values from the passed in ConnectionConfig
+func (c *ConnectionConfig) ToOption() ConnectionConfigOption {
+	return func(to *ConnectionConfig) {
+		to.URI = c.URI
+		to.DSN = c.DSN
+		to.ConnectionString = c.ConnectionString
+		to.Engine = c.Engine
+	}
+}
+
+// DebugMap returns a map form of ConnectionConfig for debugging
+func (c *ConnectionConfig) DebugMap() map[string]any {
+	debugMap, _ := c.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of ConnectionConfig for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (c *ConnectionConfig) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(c).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
+	debugMap := map[string]any{}
+	if c.URI == "" {
+		debugMap["URI"] = "(empty)"
+	} else {
+		debugMap["URI"] = redact.ConnectionString(c.URI)
+	}
+	if c.DSN == nil {
+		debugMap["DSN"] = "nil"
+	} else if *c.DSN == "" {
+		debugMap["DSN"] = "(empty)"
+	} else {
+		debugMap["DSN"] = redact.ConnectionString(*c.DSN)
+	}
+	if !c.ConnectionString.Valid {
+		debugMap["ConnectionString"] = "nil"
+	} else if c.ConnectionString.String == "" {
+		debugMap["ConnectionString"] = "(empty)"
+	} else {
+		debugMap["ConnectionString"] = redact.ConnectionString(c.ConnectionString.String)
+	}
+	if c.Engine == "" {
+		debugMap["Engine"] = "(empty)"
+	} else {
+		debugMap["Engine"] = c.Engine
+	}
+	return debugMap
+}
+
+// FlatDebugMap returns a flattened map form of ConnectionConfig for debugging
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
+func (c *ConnectionConfig) FlatDebugMap() map[string]any {
+	var flatten func(m map[string]any) map[string]any
+	flatten = func(m map[string]any) map[string]any {
+		result := make(map[string]any, len(m))
+		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
+			childMap, ok := value.(map[string]any)
+			if ok && len(childMap) > 0 {
+				for childKey, childValue := range flatten(childMap) {
+					result[key+"."+childKey] = childValue
+				}
+				continue
+			}
+			result[key] = value
+		}
+		return result
+	}
+	return flatten(c.DebugMap())
+}
+
+// ConnectionConfigWithOptions configures an existing ConnectionConfig with the passed in options set
+func ConnectionConfigWithOptions(c *ConnectionConfig, opts ...ConnectionConfigOption) *ConnectionConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithOptions configures the receiver ConnectionConfig with the passed in options set
+func (c *ConnectionConfig) WithOptions(opts ...ConnectionConfigOption) *ConnectionConfig {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithURI returns an option that can set URI on a ConnectionConfig
+//
+// DebugMap shows URI with its secrets replaced by "xxxxx".
+func WithURI(uRI string) ConnectionConfigOption {
+	return func(c *ConnectionConfig) {
+		c.URI = uRI
+	}
+}
+
+// WithDSN returns an option that can set DSN on a ConnectionConfig
+//
+// DebugMap shows DSN with its secrets replaced by "xxxxx".
+func WithDSN(dSN *string) ConnectionConfigOption {
+	return func(c *ConnectionConfig) {
+		c.DSN = dSN
+	}
+}
+
+// WithConnectionString returns an option that can set ConnectionString on a ConnectionConfig
+//
+// DebugMap shows ConnectionString with its secrets replaced by "xxxxx".
+func WithConnectionString(connectionString sql.NullString) ConnectionConfigOption {
+	return func(c *ConnectionConfig) {
+		c.ConnectionString = connectionString
+	}
+}
+
+// WithEngine returns an option that can set Engine on a ConnectionConfig
+func WithEngine(engine string) ConnectionConfigOption {
+	return func(c *ConnectionConfig) {
+		c.Engine = engine
+	}
+}
@@ -0,0 +1,13 @@
+package testdata
+
+import (
+	"database/sql"
+)
+
+// ConnectionConfig tests url-redacted connection strings
+type ConnectionConfig struct {
+	URI              string         `debugmap:"url-redacted"`
+	DSN              *string        `debugmap:"url-redacted"`
+	ConnectionString sql.NullString `debugmap:"url-redacted"`
+	Engine           string         `debugmap:"visible"`
+}
@@ -1,9 +1,11 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
+// optgen hashes: source=28a419f7e52b3b3b settings=881fc53de12c1b74 body=fadc536f7712ffc9
 package testdata
 
 import (
-	"fmt"
 	defaults "github.com/creasty/defaults"
+	"reflect"
+	"strconv"
 )
 
 type FormatTestOption func(f *FormatTest)
@@ -38,6 +40,22 @@ func (f *FormatTest) ToOption() FormatTestOption {
 
 // DebugMap returns a map form of FormatTest for debugging
 func (f *FormatTest) DebugMap() map[string]any {
+	debugMap, _ := f.debugMapWithState(0, map[uintptr]struct{}{}).(map[string]any)
+	return debugMap
+}
+
+// debugMapWithState returns a map form of FormatTest for debugging, tracking the nesting
+// depth and the structs being expanded so that cycles and nesting deeper than 10
+// levels are replaced with "(cycle)" and "(max depth)" placeholders
+func (f *FormatTest) debugMapWithState(depth int, seen map[uintptr]struct{}) any {
+	if depth > 10 {
+		return "(max depth)"
+	}
+	ptr := reflect.ValueOf(f).Pointer()
+	if _, ok := seen[ptr]; !ok {
+		seen[ptr] = struct{}{}
+		defer delete(seen, ptr)
+	}
 	debugMap := map[string]any{}
 	if f.Name == "" {
 		debugMap["Name"] = "(empty)"
@@ -47,21 +65,42 @@ func (f *FormatTest) DebugMap() map[string]any {
 	if f.Data == nil {
 		debugMap["Data"] = "nil"
 	} else {
-		debugMap["Data"] = fmt.Sprintf("%v", f.Data)
+		debugData := make(map[string]any, len(f.Data))
+		for k, v := range f.Data {
+			if v == "" {
+				debugData[k] = "(empty)"
+			} else {
+				debugData[k] = v
+			}
+		}
+		debugMap["Data"] = debugData
 	}
 	debugMap["Count"] = f.Count
 	return debugMap
 }
 
 // FlatDebugMap returns a flattened map form of FormatTest for debugging
-// Nested maps are flattened using dot notation (e.g., "parent.child.field")
+// Nested maps are flattened using dot notation (e.g., "parent.child.field"), and slices
+// containing nested maps are flattened by index (e.g., "parent.0.field")
 func (f *FormatTest) FlatDebugMap() map[string]any {
 	var flatten func(m map[string]any) map[string]any
 	flatten = func(m map[string]any) map[string]any {
 		result := make(map[string]any, len(m))
 		for key, value := range m {
+			if items, ok := value.([]any); ok {
+				indexed := make(map[string]any, len(items))
+				hasMap := false
+				for i, item := range items {
+					_, isMap := item.(map[string]any)
+					hasMap = hasMap || isMap
+					indexed[strconv.Itoa(i)] = item
+				}
+				if hasMap {
+					value = indexed
+				}
+			}
 			childMap, ok := value.(map[string]any)
-			if ok {
+			if ok && len(childMap) > 0 {
 				for childKey, childValue := range flatten(childMap) {
 					result[key+"."+childKey] = childValue
 				}